@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+	"github.com/diegoserranor/cadence/internal/config"
+)
+
+// `cadence config` prints the config that applies in the working directory,
+// the user config with any `.cadence.toml` project files layered over it.
+func runConfig(args []string) int {
+	flags := flag.NewFlagSet("cadence config", flag.ExitOnError)
+	showSources := flags.Bool("show-sources", false, "list each value with the file that set it")
+	flags.Parse(args)
+
+	if *showSources {
+		sources, err := config.Sources()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read config: %v\n", err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+		for _, source := range sources {
+			if source.Ignored {
+				fmt.Fprintf(os.Stderr, "warning: ignoring %s in %s; only the user config may set it\n", source.Key, source.Path)
+				continue
+			}
+			path := source.Path
+			if path == "" {
+				path = "default"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", source.Key, source.Value, path)
+		}
+		if err := tw.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write config: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	cfg, err := config.LoadFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read config: %v\n", err)
+		return 1
+	}
+	if err := toml.NewEncoder(os.Stdout).Encode(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write config: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/diegoserranor/cadence/internal/config"
+	"github.com/diegoserranor/cadence/internal/control"
+	"github.com/diegoserranor/cadence/internal/logs"
+)
+
+// `cadence daemon` runs the timer without a UI and serves it on the control socket until interrupted.
+// Running `cadence` while the daemon is up attaches the TUI to it.
+func runDaemon(args []string) int {
+	flags := flag.NewFlagSet("cadence daemon", flag.ExitOnError)
+	debug := flags.Bool("debug", false, "enable debug logging")
+	profile := flags.String("profile", "", "apply this profile from the config")
+	mode := flags.String("mode", "", "timing technique: pomodoro or flowtime")
+	workMinutes := flags.Int("work", 0, "work phase length in minutes")
+	breakMinutes := flags.Int("break", 0, "break phase length in minutes")
+	httpAddr := flags.String("http", "", "also serve the web dashboard and HTTP API on this address, e.g. :8080")
+	flags.Parse(args)
+
+	appLogger := logs.New()
+	defer appLogger.Clean()
+	appLogger.SetEnabled(*debug)
+
+	cfg, err := config.LoadWithOverrides(*profile, *mode, *workMinutes, *breakMinutes)
+	if *profile != "" && errors.Is(err, config.ErrUnknownProfile) {
+		fmt.Fprintf(os.Stderr, "cadence daemon: %v\n", err)
+		return 2
+	}
+	if err != nil {
+		appLogger.Printf("config load failed: %v", err)
+	}
+
+	// Claim the socket first: a second daemon must back out before it touches the session, history or tasks.
+	server, err := control.Bind(control.SocketPath(), appLogger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cadence daemon: %v\n", err)
+		return 1
+	}
+	defer server.Close()
+
+	// Nobody is around to answer the resume prompt, so a saved session is always picked up.
+	m := newMachine(cfg, openTasks(cfg, appLogger), openHistory(appLogger), appLogger, resumeSaved)
+	hubSub, _ := m.Subscribe()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	m.Run(ctx)
+	hub := control.NewHub(m, hubSub, appLogger)
+	server.Serve(hub)
+	if *httpAddr != "" {
+		if err := serveHTTP(*httpAddr, hub, appLogger); err != nil {
+			fmt.Fprintf(os.Stderr, "cadence daemon: %v\n", err)
+			return 1
+		}
+	}
+
+	<-ctx.Done()
+	return 0
+}
@@ -1,36 +1,237 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
 
+	"github.com/charmbracelet/huh"
 	"github.com/diegoserranor/cadence/internal/config"
+	"github.com/diegoserranor/cadence/internal/control"
+	"github.com/diegoserranor/cadence/internal/history"
 	"github.com/diegoserranor/cadence/internal/logs"
 	"github.com/diegoserranor/cadence/internal/notify"
 	"github.com/diegoserranor/cadence/internal/pomodoro"
+	"github.com/diegoserranor/cadence/internal/tasks"
 	"github.com/diegoserranor/cadence/internal/tui"
+	"github.com/diegoserranor/cadence/internal/web"
 )
 
 func main() {
-	debug := flag.Bool("debug", false, "enable debug logging")
-	workMinutes := flag.Int("work", 0, "work phase length in minutes")
-	breakMinutes := flag.Int("break", 0, "break phase length in minutes")
-	flag.Parse()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "stats":
+			os.Exit(runStats(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "daemon":
+			os.Exit(runDaemon(os.Args[2:]))
+		case "status":
+			os.Exit(runStatus(os.Args[2:]))
+		case "start", "pause", "resume", "toggle", "skip":
+			os.Exit(runRemote(os.Args[1], os.Args[2:]))
+		}
+	}
+	runTUI(os.Args[1:])
+}
+
+// Without a subcommand cadence runs the timer in the TUI.
+func runTUI(args []string) {
+	flags := flag.NewFlagSet("cadence", flag.ExitOnError)
+	debug := flags.Bool("debug", false, "enable debug logging")
+	profile := flags.String("profile", "", "apply this profile from the config")
+	mode := flags.String("mode", "", "timing technique: pomodoro or flowtime")
+	workMinutes := flags.Int("work", 0, "work phase length in minutes")
+	breakMinutes := flags.Int("break", 0, "break phase length in minutes")
+	httpAddr := flags.String("http", "", "also serve the web dashboard and HTTP API on this address, e.g. :8080")
+	flags.Parse(args)
 
 	appLogger := logs.New()
 	defer appLogger.Clean()
 	appLogger.SetEnabled(*debug)
 
-	cfg, err := config.LoadWithOverrides(*workMinutes, *breakMinutes)
+	cfg, err := config.LoadWithOverrides(*profile, *mode, *workMinutes, *breakMinutes)
+	if *profile != "" && errors.Is(err, config.ErrUnknownProfile) {
+		fmt.Fprintf(os.Stderr, "cadence: %v\n", err)
+		os.Exit(2)
+	}
 	if err != nil && appLogger != nil {
 		appLogger.Printf("config load failed: %v", err)
 	}
+	// The TUI and the machine's subscribers share these, so both see the same tasks and history.
+	store := openHistory(appLogger)
+	taskSource := openTasks(cfg, appLogger)
+
+	// Attach to a running daemon or TUI rather than starting a second timer.
+	if client, err := control.Dial(control.SocketPath(), appLogger); err == nil {
+		defer client.Close()
+		if *httpAddr != "" {
+			appLogger.Printf("ignoring --http while attached; pass it to the running instance instead")
+		}
+		events, err := client.Subscribe()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "cadence: attach failed:", err)
+			os.Exit(1)
+		}
+		tui.Run(events, client, cfg, store, taskSource, appLogger)
+		return
+	}
+
+	// Claim the socket before building the machine, so a second instance never resumes or records the first one's session.
+	// `cadence toggle` and friends reach the machine through it while the TUI is open.
+	server, err := control.Bind(control.SocketPath(), appLogger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cadence: %v\n", err)
+		os.Exit(1)
+	}
+	defer server.Close()
+
+	m := newMachine(cfg, taskSource, store, appLogger, offerResume)
+	tuiSub, _ := m.Subscribe()
+	hubSub, _ := m.Subscribe()
+	// Stopping the machine on the way out closes every subscription, ending their goroutines.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.Run(ctx)
+	hub := control.NewHub(m, hubSub, appLogger)
+	server.Serve(hub)
+	if *httpAddr != "" {
+		if err := serveHTTP(*httpAddr, hub, appLogger); err != nil {
+			fmt.Fprintf(os.Stderr, "cadence: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	tui.Run(tuiSub, m, cfg, store, taskSource, appLogger)
+}
+
+// Build the machine along with the subscribers every instance needs: notifications, history and tasks.
+// `taskSource` and `store` may be nil when there is nowhere to keep tasks or history.
+// `resume` decides whether to pick up a session left behind by a previous run.
+// The caller runs the machine once it has subscribed, and before sending it commands.
+func newMachine(cfg config.Config, taskSource tasks.Source, store *history.Store, appLogger logs.Logger, resume func(path string) (pomodoro.Session, bool)) *pomodoro.Machine {
+	opts := optionsFromConfig(cfg)
+	if sessionPath, err := pomodoro.SessionPath(); err == nil {
+		opts = append(opts, pomodoro.WithSessionFile(sessionPath))
+		if session, ok := resume(sessionPath); ok {
+			opts = append(opts, pomodoro.WithSession(session))
+		}
+	} else {
+		appLogger.Printf("session path unavailable: %v", err)
+	}
 
-	m := pomodoro.NewMachine(appLogger, cfg.WorkMinutes, cfg.BreakMinutes, cfg.WorkPhases)
-	m.Run()
+	m := pomodoro.NewMachine(appLogger, planFromConfig(cfg), opts...)
 
-	notifySub := m.Subscribe()
+	notifySub, _ := m.Subscribe()
 	notify.Run(notifySub)
 
-	tuiSub := m.Subscribe()
-	tui.Run(tuiSub, m, cfg, appLogger)
+	var activeTask func() string
+	if taskSource != nil {
+		tasksSub, _ := m.Subscribe()
+		tasks.Run(tasksSub, taskSource, appLogger)
+		activeTask = func() string {
+			task, _, _ := taskSource.Active()
+			return task.Name
+		}
+	}
+
+	if store != nil {
+		historySub, _ := m.Subscribe()
+		history.Run(historySub, store, activeTask, appLogger)
+	}
+	return m
+}
+
+// Serve the web dashboard and the HTTP API in the background. Binding errors are reported right away.
+func serveHTTP(addr string, hub *control.Hub, appLogger logs.Logger) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("http listen failed: %w", err)
+	}
+	go func() {
+		if err := http.Serve(listener, web.Handler(control.NewHTTPHandler(hub))); err != nil {
+			appLogger.Printf("http server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// Open the history store, or nil when there is nowhere to keep it.
+func openHistory(appLogger logs.Logger) *history.Store {
+	historyPath, err := history.Path()
+	if err != nil {
+		appLogger.Printf("history path unavailable: %v", err)
+		return nil
+	}
+	return history.NewStore(historyPath)
+}
+
+// Open the configured todo.txt file or cadence's own task list, or nil when there is nowhere to keep tasks.
+func openTasks(cfg config.Config, appLogger logs.Logger) tasks.Source {
+	if todoPath := cfg.TodoTxtPath(); todoPath != "" {
+		activePath, err := tasks.TodoTxtActivePath()
+		if err != nil {
+			appLogger.Printf("tasks path unavailable: %v", err)
+			return nil
+		}
+		return tasks.NewTodoTxt(todoPath, activePath)
+	}
+
+	tasksPath, err := tasks.Path()
+	if err != nil {
+		appLogger.Printf("tasks path unavailable: %v", err)
+		return nil
+	}
+	return tasks.NewStore(tasksPath)
+}
+
+// Pick up a session left behind by a previous run without asking.
+func resumeSaved(path string) (pomodoro.Session, bool) {
+	session, err := pomodoro.LoadSession(path)
+	if err != nil || !session.Resumable() {
+		return pomodoro.Session{}, false
+	}
+	return session, true
+}
+
+// Ask whether to pick up a session left behind by a previous run.
+func offerResume(path string) (pomodoro.Session, bool) {
+	session, ok := resumeSaved(path)
+	if !ok {
+		return pomodoro.Session{}, false
+	}
+
+	resume := true
+	err := huh.NewConfirm().
+		Title("Resume previous session?").
+		Description(describeSession(session)).
+		Affirmative("Resume").
+		Negative("Start over").
+		Value(&resume).
+		Run()
+	if err != nil {
+		return pomodoro.Session{}, false
+	}
+	if !resume {
+		_ = os.Remove(path)
+		return pomodoro.Session{}, false
+	}
+	return session, true
+}
+
+func describeSession(session pomodoro.Session) string {
+	status := "running"
+	if session.Status == pomodoro.StatusPaused {
+		status = "paused"
+	}
+	kind := pomodoro.PhaseWork
+	if session.PhaseIdx >= 0 && session.PhaseIdx < len(session.Plan) {
+		kind = session.Plan[session.PhaseIdx].Kind
+	} else if session.PhaseIdx%2 == 1 {
+		kind = pomodoro.PhaseBreak
+	}
+	return fmt.Sprintf("%s phase %s since %s", kind, status, session.SavedAt.Local().Format("Mon 15:04"))
 }
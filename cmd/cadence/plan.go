@@ -0,0 +1,52 @@
+package main
+
+import (
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/config"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Build the phase plan described by a config.
+// An explicit `[[phases]]` list wins; otherwise work and break phases alternate.
+func planFromConfig(cfg config.Config) []pomodoro.PhaseDetail {
+	if len(cfg.Phases) > 0 {
+		plan := make([]pomodoro.PhaseDetail, 0, len(cfg.Phases))
+		for _, phase := range cfg.Phases {
+			plan = append(plan, pomodoro.PhaseDetail{
+				Kind:     phaseKindFromConfig(phase.Kind),
+				Duration: time.Duration(phase.Minutes) * time.Minute,
+				Label:    phase.Label,
+			})
+		}
+		return plan
+	}
+
+	return pomodoro.AlternatingPlan(
+		time.Duration(cfg.WorkMinutes)*time.Minute,
+		time.Duration(cfg.BreakMinutes)*time.Minute,
+		time.Duration(cfg.LongBreakMinutes)*time.Minute,
+		cfg.WorkPhases,
+		cfg.LongBreakEvery,
+	)
+}
+
+// Build the machine options described by a config.
+func optionsFromConfig(cfg config.Config) []pomodoro.Option {
+	opts := []pomodoro.Option{pomodoro.WithAutoAdvance(cfg.AutoAdvanceEnabled())}
+	if cfg.Mode == config.ModeFlowtime {
+		opts = append(opts, pomodoro.WithFlowtime(cfg.FlowtimeBreakRatio))
+	}
+	return opts
+}
+
+func phaseKindFromConfig(kind string) pomodoro.PhaseKind {
+	switch kind {
+	case config.PhaseKindBreak:
+		return pomodoro.PhaseBreak
+	case config.PhaseKindLongBreak:
+		return pomodoro.PhaseLongBreak
+	default:
+		return pomodoro.PhaseWork
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/diegoserranor/cadence/internal/control"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+	"github.com/diegoserranor/cadence/internal/statusline"
+)
+
+// Subcommands that drive an instance already running in the TUI or as a daemon, and the protocol command each sends.
+var remoteCommands = map[string]string{
+	"start":  control.CommandStart,
+	"pause":  control.CommandPause,
+	"resume": control.CommandResume,
+	"toggle": control.CommandToggle,
+	"skip":   control.CommandSkip,
+}
+
+var errNotRunning = errors.New("cadence is not running; start it with `cadence` or `cadence daemon`")
+
+// `cadence start|pause|resume|toggle|skip` send a single command to the running instance.
+func runRemote(name string, args []string) int {
+	flags := flag.NewFlagSet("cadence "+name, flag.ExitOnError)
+	flags.Parse(args)
+
+	client, err := dialRunning()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer client.Close()
+
+	if _, err := client.Send(control.Request{Command: remoteCommands[name]}); err != nil {
+		fmt.Fprintf(os.Stderr, "cadence %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// `cadence status` prints the state of the running timer on one line, once or on every change with `--follow`.
+func runStatus(args []string) int {
+	flags := flag.NewFlagSet("cadence status", flag.ExitOnError)
+	format := flags.String("format", statusline.FormatPlain, "plain, waybar, tmux or a Go template such as '{{.Kind}} {{.Remaining}}'")
+	follow := flags.Bool("follow", false, "print a new line whenever the output changes")
+	flags.Parse(args)
+
+	formatter, err := statusline.New(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cadence status: %v\n", err)
+		return 2
+	}
+
+	client, err := dialRunning()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer client.Close()
+
+	if !*follow {
+		state, err := client.State()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cadence status: %v\n", err)
+			return 1
+		}
+		line, err := formatter(state)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cadence status: %v\n", err)
+			return 1
+		}
+		fmt.Println(line)
+		return 0
+	}
+
+	events, err := client.Subscribe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cadence status: %v\n", err)
+		return 1
+	}
+	// The machine reports its state several times a second, so only print when the line changes.
+	var last string
+	for event := range events {
+		state, ok := event.(pomodoro.EventStateChanged)
+		if !ok {
+			continue
+		}
+		line, err := formatter(state)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cadence status: %v\n", err)
+			return 1
+		}
+		if line != last {
+			fmt.Println(line)
+			last = line
+		}
+	}
+	fmt.Fprintln(os.Stderr, "cadence status: connection to cadence closed")
+	return 1
+}
+
+func dialRunning() (*control.Client, error) {
+	client, err := control.Dial(control.SocketPath(), nil)
+	if err != nil {
+		return nil, errNotRunning
+	}
+	return client, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/history"
+	"github.com/diegoserranor/cadence/internal/stats"
+)
+
+// A bucket as printed by `cadence stats`. Durations are whole minutes so spreadsheets can sum them.
+type statsRow struct {
+	Key             string `json:"key"`
+	WorkPhases      int    `json:"work_phases"`
+	Skipped         int    `json:"skipped"`
+	FocusMinutes    int    `json:"focus_minutes"`
+	OvertimeMinutes int    `json:"overtime_minutes"`
+}
+
+// `cadence stats` prints aggregated history without starting the TUI.
+func runStats(args []string) int {
+	flags := flag.NewFlagSet("cadence stats", flag.ExitOnError)
+	since := flags.String("since", "", "only include phases that ended on or after this date (YYYY-MM-DD)")
+	by := flags.String("by", string(stats.ByDay), "group by day, week, tag or task")
+	format := flags.String("format", "table", "output format: table, json or csv")
+	tag := flags.String("tag", "", "only include work phases with this tag")
+	note := flags.String("note", "", "only include work phases whose note contains this text")
+	flags.Parse(args)
+
+	groupBy, err := stats.ParseGroupBy(*by)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var from time.Time
+	if *since != "" {
+		from, err = time.ParseInLocation(time.DateOnly, *since, time.Local)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --since date %q, expected YYYY-MM-DD\n", *since)
+			return 2
+		}
+	}
+
+	path, err := history.Path()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "history unavailable: %v\n", err)
+		return 1
+	}
+	records, err := history.NewStore(path).LoadSince(from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read history: %v\n", err)
+		return 1
+	}
+
+	records = stats.Filter{Tag: *tag, Note: *note}.Apply(records)
+	rows := statsRows(stats.Group(records, groupBy, time.Local))
+	switch *format {
+	case "table":
+		err = writeStatsTable(os.Stdout, rows)
+	case "json":
+		err = writeStatsJSON(os.Stdout, rows)
+	case "csv":
+		err = writeStatsCSV(os.Stdout, rows)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q, expected table, json or csv\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write stats: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func statsRows(buckets []stats.Bucket) []statsRow {
+	rows := make([]statsRow, 0, len(buckets))
+	for _, bucket := range buckets {
+		rows = append(rows, statsRow{
+			Key:             bucket.Key,
+			WorkPhases:      bucket.WorkPhases,
+			Skipped:         bucket.Skipped,
+			FocusMinutes:    int(bucket.Focus.Minutes()),
+			OvertimeMinutes: int(bucket.Overtime.Minutes()),
+		})
+	}
+	return rows
+}
+
+func writeStatsTable(w io.Writer, rows []statsRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tWORK PHASES\tSKIPPED\tFOCUS MIN\tOVERTIME MIN")
+	for _, row := range rows {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", row.Key, row.WorkPhases, row.Skipped, row.FocusMinutes, row.OvertimeMinutes)
+	}
+	return tw.Flush()
+}
+
+func writeStatsJSON(w io.Writer, rows []statsRow) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
+
+func writeStatsCSV(w io.Writer, rows []statsRow) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"key", "work_phases", "skipped", "focus_minutes", "overtime_minutes"})
+	for _, row := range rows {
+		writer.Write([]string{
+			row.Key,
+			strconv.Itoa(row.WorkPhases),
+			strconv.Itoa(row.Skipped),
+			strconv.Itoa(row.FocusMinutes),
+			strconv.Itoa(row.OvertimeMinutes),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
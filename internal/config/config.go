@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -11,22 +15,119 @@ const (
 	defaultWorkMinutes  = 25
 	defaultBreakMinutes = 5
 	defaultWorkPhases   = 4
+
+	defaultLongBreakMinutes = 15
+	defaultLongBreakEvery   = 0
+
+	defaultMode               = ModePomodoro
+	defaultFlowtimeBreakRatio = 0.2
+)
+
+// Timing techniques accepted by the `mode` key.
+const (
+	ModePomodoro = "pomodoro"
+	ModeFlowtime = "flowtime"
 )
 
 type Config struct {
+	// Profile applied over these settings unless another is picked with `--profile`.
+	Profile string `toml:"profile,omitempty"`
+
+	Mode string `toml:"mode"`
+
 	WorkMinutes  int `toml:"work_minutes"`
 	BreakMinutes int `toml:"break_minutes"`
 	WorkPhases   int `toml:"work_phases"`
+
+	// A long break replaces every Nth break. Zero disables long breaks.
+	LongBreakMinutes int `toml:"long_break_minutes"`
+	LongBreakEvery   int `toml:"long_break_every"`
+
+	// Flowtime breaks last this fraction of the preceding work time.
+	FlowtimeBreakRatio float64 `toml:"flowtime_break_ratio"`
+
+	// When false, a finished phase counts overtime until you move on explicitly.
+	// Nil means the default, which is to advance automatically.
+	AutoAdvance *bool `toml:"auto_advance,omitempty"`
+
+	// Ask for tags and a note when a work phase ends.
+	NotePrompt bool `toml:"note_prompt,omitempty"`
+
+	// Keep tasks in this todo.txt file instead of cadence's own task list.
+	// A leading `~/` stands for the home directory.
+	TodoTxt string `toml:"todo_txt,omitempty"`
+
+	// An explicit phase plan declared as `[[phases]]` tables.
+	// When present it takes precedence over the work/break settings above.
+	Phases []Phase `toml:"phases,omitempty"`
+
+	// Named sets of settings declared as `[profiles.<name>]` tables.
+	Profiles map[string]Profile `toml:"profiles,omitempty"`
+}
+
+// Settings a profile overrides. Unset fields keep the base value.
+type Profile struct {
+	Mode string `toml:"mode,omitempty"`
+
+	WorkMinutes  int `toml:"work_minutes,omitzero"`
+	BreakMinutes int `toml:"break_minutes,omitzero"`
+	WorkPhases   int `toml:"work_phases,omitzero"`
+
+	LongBreakMinutes int  `toml:"long_break_minutes,omitzero"`
+	LongBreakEvery   *int `toml:"long_break_every,omitempty"`
+
+	FlowtimeBreakRatio float64 `toml:"flowtime_break_ratio,omitzero"`
+	AutoAdvance        *bool   `toml:"auto_advance,omitempty"`
+
+	Phases []Phase `toml:"phases,omitempty"`
+}
+
+// Returned when a profile is picked that the config does not declare.
+var ErrUnknownProfile = errors.New("unknown profile")
+
+// Phase kinds accepted in `[[phases]]` tables.
+const (
+	PhaseKindWork      = "work"
+	PhaseKindBreak     = "break"
+	PhaseKindLongBreak = "long_break"
+)
+
+type Phase struct {
+	Kind    string `toml:"kind"`
+	Minutes int    `toml:"minutes"`
+	Label   string `toml:"label,omitempty"`
 }
 
 func Default() Config {
 	return Config{
+		Mode: defaultMode,
+
 		WorkMinutes:  defaultWorkMinutes,
 		BreakMinutes: defaultBreakMinutes,
 		WorkPhases:   defaultWorkPhases,
+
+		LongBreakMinutes: defaultLongBreakMinutes,
+		LongBreakEvery:   defaultLongBreakEvery,
+
+		FlowtimeBreakRatio: defaultFlowtimeBreakRatio,
 	}
 }
 
+// Reports whether phases roll over into the next one as soon as they end.
+func (c Config) AutoAdvanceEnabled() bool {
+	return c.AutoAdvance == nil || *c.AutoAdvance
+}
+
+// The todo.txt path with `~/` expanded, or "" when tasks are kept by cadence.
+func (c Config) TodoTxtPath() string {
+	if rest, ok := strings.CutPrefix(c.TodoTxt, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return c.TodoTxt
+}
+
 func Path() (string, error) {
 	dir, err := os.UserConfigDir()
 	if err != nil {
@@ -35,34 +136,114 @@ func Path() (string, error) {
 	return filepath.Join(dir, "cadence", "config.toml"), nil
 }
 
+// Profile names in alphabetical order.
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// The settings with profile `name` merged over them. An empty name returns the base settings.
+// The result remembers the profile in `Profile`.
+func (c Config) WithProfile(name string) (Config, error) {
+	if name == "" {
+		c.Profile = ""
+		return c, nil
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return c, fmt.Errorf("%w %q", ErrUnknownProfile, name)
+	}
+
+	c.Profile = name
+	if profile.Mode != "" {
+		c.Mode = profile.Mode
+	}
+	if profile.WorkMinutes > 0 {
+		c.WorkMinutes = profile.WorkMinutes
+	}
+	if profile.BreakMinutes > 0 {
+		c.BreakMinutes = profile.BreakMinutes
+	}
+	if profile.WorkPhases > 0 {
+		c.WorkPhases = profile.WorkPhases
+	}
+	if profile.LongBreakMinutes > 0 {
+		c.LongBreakMinutes = profile.LongBreakMinutes
+	}
+	if profile.LongBreakEvery != nil {
+		c.LongBreakEvery = *profile.LongBreakEvery
+	}
+	if profile.FlowtimeBreakRatio > 0 {
+		c.FlowtimeBreakRatio = profile.FlowtimeBreakRatio
+	}
+	if profile.AutoAdvance != nil {
+		c.AutoAdvance = profile.AutoAdvance
+	}
+	if len(profile.Phases) > 0 {
+		c.Phases = profile.Phases
+	}
+	return normalize(c), nil
+}
+
+// Load the config with its default profile applied.
 func Load() (Config, error) {
-	path, err := Path()
+	return LoadProfile("")
+}
+
+// Load the config with profile `name` applied, or its default profile when `name` is empty.
+// An unknown profile leaves the base settings in place and reports `ErrUnknownProfile`.
+func LoadProfile(name string) (Config, error) {
+	cfg, err := LoadFile()
 	if err != nil {
-		return Default(), err
+		return cfg, err
 	}
+	if name == "" {
+		name = cfg.Profile
+	}
+	return cfg.WithProfile(name)
+}
 
-	if _, err := os.Stat(path); err != nil {
-		if os.IsNotExist(err) {
-			return Default(), nil
-		}
+// Load the config files as written, the user config with any project files layered over it,
+// without applying a profile.
+func LoadFile() (Config, error) {
+	paths, err := Paths()
+	if err != nil {
 		return Default(), err
 	}
-
-	var cfg Config
-	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+	cfg, _, _, err := decodeLayers(paths)
+	if err != nil {
 		return Default(), err
 	}
+	return normalize(cfg), nil
+}
 
+// Load only the user config at `Path`, without project files or a profile. This is the file `Save` writes.
+func LoadUserFile() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Default(), err
+	}
+	cfg, _, _, err := decodeLayers([]string{path})
+	if err != nil {
+		return Default(), err
+	}
 	return normalize(cfg), nil
 }
 
-func LoadWithOverrides(workMinutes, breakMinutes int) (Config, error) {
-	cfg, err := Load()
-	cfg = ApplyOverrides(cfg, workMinutes, breakMinutes)
+func LoadWithOverrides(profile, mode string, workMinutes, breakMinutes int) (Config, error) {
+	cfg, err := LoadProfile(profile)
+	cfg = ApplyOverrides(cfg, mode, workMinutes, breakMinutes)
 	return cfg, err
 }
 
-func ApplyOverrides(cfg Config, workMinutes, breakMinutes int) Config {
+func ApplyOverrides(cfg Config, mode string, workMinutes, breakMinutes int) Config {
+	if mode != "" {
+		cfg.Mode = mode
+	}
 	if workMinutes > 0 {
 		cfg.WorkMinutes = workMinutes
 	}
@@ -94,6 +275,10 @@ func Save(cfg Config) error {
 }
 
 func normalize(cfg Config) Config {
+	cfg.Mode = strings.ToLower(strings.TrimSpace(cfg.Mode))
+	if cfg.Mode != ModePomodoro && cfg.Mode != ModeFlowtime {
+		cfg.Mode = defaultMode
+	}
 	if cfg.WorkMinutes <= 0 {
 		cfg.WorkMinutes = defaultWorkMinutes
 	}
@@ -103,5 +288,45 @@ func normalize(cfg Config) Config {
 	if cfg.WorkPhases <= 0 {
 		cfg.WorkPhases = defaultWorkPhases
 	}
+	if cfg.LongBreakMinutes <= 0 {
+		cfg.LongBreakMinutes = defaultLongBreakMinutes
+	}
+	if cfg.LongBreakEvery < 0 {
+		cfg.LongBreakEvery = defaultLongBreakEvery
+	}
+	if cfg.FlowtimeBreakRatio <= 0 {
+		cfg.FlowtimeBreakRatio = defaultFlowtimeBreakRatio
+	}
+	cfg.TodoTxt = strings.TrimSpace(cfg.TodoTxt)
+	cfg.Phases = normalizePhases(cfg.Phases)
+	for name, profile := range cfg.Profiles {
+		profile.Mode = strings.ToLower(strings.TrimSpace(profile.Mode))
+		profile.Phases = normalizePhases(profile.Phases)
+		cfg.Profiles[name] = profile
+	}
 	return cfg
 }
+
+// Drops phases with an unknown kind or a non-positive length.
+func normalizePhases(phases []Phase) []Phase {
+	if len(phases) == 0 {
+		return nil
+	}
+	valid := make([]Phase, 0, len(phases))
+	for _, phase := range phases {
+		phase.Kind = strings.ToLower(strings.TrimSpace(phase.Kind))
+		switch phase.Kind {
+		case PhaseKindWork, PhaseKindBreak, PhaseKindLongBreak:
+		default:
+			continue
+		}
+		if phase.Minutes <= 0 {
+			continue
+		}
+		valid = append(valid, phase)
+	}
+	if len(valid) == 0 {
+		return nil
+	}
+	return valid
+}
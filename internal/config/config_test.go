@@ -0,0 +1,228 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadProfileMergesOverBase(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	path, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := `
+profile = "meetings"
+work_minutes = 30
+break_minutes = 5
+long_break_every = 4
+
+[profiles.deep]
+work_minutes = 90
+break_minutes = 20
+long_break_every = 0
+
+[profiles.meetings]
+work_minutes = 15
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.Profile != "meetings" || cfg.WorkMinutes != 15 || cfg.BreakMinutes != 5 || cfg.LongBreakEvery != 4 {
+		t.Fatalf("expected the default profile over the base settings, got %+v", cfg)
+	}
+
+	deep, err := LoadProfile("deep")
+	if err != nil {
+		t.Fatalf("load deep failed: %v", err)
+	}
+	if deep.WorkMinutes != 90 || deep.BreakMinutes != 20 || deep.LongBreakEvery != 0 {
+		t.Fatalf("expected the deep profile, got %+v", deep)
+	}
+
+	base, err := LoadFile()
+	if err != nil || base.WorkMinutes != 30 || len(base.ProfileNames()) != 2 {
+		t.Fatalf("expected the file as written, got %+v %v", base, err)
+	}
+
+	if _, err := LoadProfile("nope"); !errors.Is(err, ErrUnknownProfile) {
+		t.Fatalf("expected an unknown profile error, got %v", err)
+	}
+}
+
+func TestProjectFilesLayerOverUserConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("HOME", dir)
+	userPath, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(dir, "repo")
+	sub := filepath.Join(project, "docs")
+	files := map[string]string{
+		userPath:                            "work_minutes = 30\nbreak_minutes = 10\n",
+		filepath.Join(project, ProjectFile): "work_minutes = 50\nwork_phases = 2\n",
+		filepath.Join(sub, ProjectFile):     "work_minutes = 15\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(sub)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.WorkMinutes != 15 || cfg.WorkPhases != 2 || cfg.BreakMinutes != 10 {
+		t.Fatalf("expected the nearest file to win key by key, got %+v", cfg)
+	}
+	if user, err := LoadUserFile(); err != nil || user.WorkMinutes != 30 || user.WorkPhases != defaultWorkPhases {
+		t.Fatalf("expected the user config alone, got %+v %v", user, err)
+	}
+
+	sources, err := Sources()
+	if err != nil {
+		t.Fatalf("sources failed: %v", err)
+	}
+	want := map[string]string{
+		"work_minutes":  filepath.Join(sub, ProjectFile),
+		"work_phases":   filepath.Join(project, ProjectFile),
+		"break_minutes": userPath,
+		"mode":          "",
+	}
+	for _, source := range sources {
+		if path, ok := want[source.Key]; ok && source.Path != path {
+			t.Fatalf("expected %s from %q, got %q", source.Key, path, source.Path)
+		}
+	}
+}
+
+func TestProfileSplitAcrossFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("HOME", dir)
+	userPath, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(dir, "repo")
+	projectPath := filepath.Join(project, ProjectFile)
+	files := map[string]string{
+		userPath:    "[profiles.deep]\nwork_minutes = 50\nbreak_minutes = 10\n\n[profiles.light]\nwork_minutes = 15\n",
+		projectPath: "[profiles.deep]\nwork_minutes = 90\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(project)
+
+	cfg, err := LoadProfile("deep")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.WorkMinutes != 90 || cfg.BreakMinutes != 10 {
+		t.Fatalf("expected the project's work minutes and the user's break minutes, got %+v", cfg)
+	}
+	if light, err := LoadProfile("light"); err != nil || light.WorkMinutes != 15 {
+		t.Fatalf("expected the profile only the user config declares, got %+v %v", light, err)
+	}
+
+	sources, err := Sources()
+	if err != nil {
+		t.Fatalf("sources failed: %v", err)
+	}
+	want := map[string]Source{
+		"profiles.deep.work_minutes":  {Value: "90", Path: projectPath},
+		"profiles.deep.break_minutes": {Value: "10", Path: userPath},
+	}
+	found := 0
+	for _, source := range sources {
+		if w, ok := want[source.Key]; ok {
+			found++
+			if source.Value != w.Value || source.Path != w.Path {
+				t.Fatalf("expected %s = %s from %q, got %s from %q", source.Key, w.Value, w.Path, source.Value, source.Path)
+			}
+		}
+	}
+	if found != len(want) {
+		t.Fatalf("expected every profile key in the sources, got %+v", sources)
+	}
+}
+
+func TestProjectFilesCannotSetTodoTxt(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("HOME", dir)
+	userPath, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(dir, "repo")
+	projectPath := filepath.Join(project, ProjectFile)
+	files := map[string]string{
+		userPath:    "todo_txt = \"/home/me/todo.txt\"\n",
+		projectPath: "todo_txt = \"/home/me/.bashrc\"\nwork_minutes = 50\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(project)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if cfg.TodoTxt != "/home/me/todo.txt" || cfg.WorkMinutes != 50 {
+		t.Fatalf("expected the user's todo.txt and the project's other keys, got %+v", cfg)
+	}
+
+	sources, err := Sources()
+	if err != nil {
+		t.Fatalf("sources failed: %v", err)
+	}
+	var applied, ignored bool
+	for _, source := range sources {
+		if source.Key != "todo_txt" {
+			continue
+		}
+		switch {
+		case source.Ignored && source.Path == projectPath:
+			ignored = true
+		case !source.Ignored && source.Path == userPath && source.Value == `"/home/me/todo.txt"`:
+			applied = true
+		default:
+			t.Fatalf("unexpected todo_txt source %+v", source)
+		}
+	}
+	if !applied || !ignored {
+		t.Fatalf("expected the user's todo_txt applied and the project's ignored, got %+v", sources)
+	}
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Name of the per-project config file looked up from the working directory.
+const ProjectFile = ".cadence.toml"
+
+// Project config files that apply in `dir`: every `.cadence.toml` in it and its parents, farthest first.
+func ProjectPaths(dir string) []string {
+	paths := make([]string, 0)
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			paths = append(paths, path)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	slices.Reverse(paths)
+	return paths
+}
+
+// Every config file that applies in the working directory, in the order they are layered:
+// the user config first, then project files from the farthest to the nearest.
+func Paths() ([]string, error) {
+	userPath, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{userPath}
+	if wd, err := os.Getwd(); err == nil {
+		paths = append(paths, ProjectPaths(wd)...)
+	}
+	return paths, nil
+}
+
+// Decode `paths` in order into one config; each file only overrides the keys it sets,
+// down to single fields of a profile split across files. Missing files are skipped.
+// The first path is the user config and the rest are project files. Project files cannot set `todo_txt`:
+// it names a file cadence rewrites, and a `.cadence.toml` in a cloned repository must not point that at another file.
+// The returned map names the file that last set each key, such as "work_minutes" or "profiles.deep.work_minutes".
+// The returned sources list the keys project files set that were ignored.
+func decodeLayers(paths []string) (Config, map[string]string, []Source, error) {
+	var cfg Config
+	sources := make(map[string]string)
+	ignored := make([]Source, 0)
+	for i, path := range paths {
+		var layer Config
+		md, err := toml.DecodeFile(path, &layer)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return cfg, sources, ignored, fmt.Errorf("%s: %w", path, err)
+		}
+		project := i > 0
+		if project && md.IsDefined("todo_txt") {
+			ignored = append(ignored, Source{Key: "todo_txt", Value: formatValue(layer.TodoTxt), Path: path})
+		}
+		mergeLayer(&cfg, layer, md, project)
+		for _, key := range md.Keys() {
+			if project && key.String() == "todo_txt" {
+				continue
+			}
+			sources[key.String()] = path
+		}
+	}
+	return cfg, sources, ignored, nil
+}
+
+// Copy the keys one file set, as told by its metadata, over `cfg`. Arrays of tables replace the earlier ones whole.
+// A project file's `todo_txt` is left out; see `decodeLayers`.
+func mergeLayer(cfg *Config, layer Config, md toml.MetaData, project bool) {
+	set := func(key string) bool { return md.IsDefined(key) }
+	if set("profile") {
+		cfg.Profile = layer.Profile
+	}
+	if set("mode") {
+		cfg.Mode = layer.Mode
+	}
+	if set("work_minutes") {
+		cfg.WorkMinutes = layer.WorkMinutes
+	}
+	if set("break_minutes") {
+		cfg.BreakMinutes = layer.BreakMinutes
+	}
+	if set("work_phases") {
+		cfg.WorkPhases = layer.WorkPhases
+	}
+	if set("long_break_minutes") {
+		cfg.LongBreakMinutes = layer.LongBreakMinutes
+	}
+	if set("long_break_every") {
+		cfg.LongBreakEvery = layer.LongBreakEvery
+	}
+	if set("flowtime_break_ratio") {
+		cfg.FlowtimeBreakRatio = layer.FlowtimeBreakRatio
+	}
+	if set("auto_advance") {
+		cfg.AutoAdvance = layer.AutoAdvance
+	}
+	if set("note_prompt") {
+		cfg.NotePrompt = layer.NotePrompt
+	}
+	if set("todo_txt") && !project {
+		cfg.TodoTxt = layer.TodoTxt
+	}
+	if set("phases") {
+		cfg.Phases = layer.Phases
+	}
+
+	for name, profile := range layer.Profiles {
+		if cfg.Profiles == nil {
+			cfg.Profiles = make(map[string]Profile)
+		}
+		merged := cfg.Profiles[name]
+		mergeProfile(&merged, profile, func(key string) bool { return md.IsDefined("profiles", name, key) })
+		cfg.Profiles[name] = merged
+	}
+}
+
+func mergeProfile(profile *Profile, layer Profile, set func(key string) bool) {
+	if set("mode") {
+		profile.Mode = layer.Mode
+	}
+	if set("work_minutes") {
+		profile.WorkMinutes = layer.WorkMinutes
+	}
+	if set("break_minutes") {
+		profile.BreakMinutes = layer.BreakMinutes
+	}
+	if set("work_phases") {
+		profile.WorkPhases = layer.WorkPhases
+	}
+	if set("long_break_minutes") {
+		profile.LongBreakMinutes = layer.LongBreakMinutes
+	}
+	if set("long_break_every") {
+		profile.LongBreakEvery = layer.LongBreakEvery
+	}
+	if set("flowtime_break_ratio") {
+		profile.FlowtimeBreakRatio = layer.FlowtimeBreakRatio
+	}
+	if set("auto_advance") {
+		profile.AutoAdvance = layer.AutoAdvance
+	}
+	if set("phases") {
+		profile.Phases = layer.Phases
+	}
+}
+
+// A config value and where it came from.
+type Source struct {
+	Key   string
+	Value string
+	// File that set the value; empty when the default applies.
+	Path string
+	// Set when a project file set a key only the user config may set, so the value does not apply.
+	Ignored bool
+}
+
+// Every value of the layered config, before any profile is applied, with the file that set it.
+// Keys are sorted; arrays of tables such as `phases` are reported as a whole.
+// Values project files set but may not are listed too, marked as ignored.
+func Sources() ([]Source, error) {
+	paths, err := Paths()
+	if err != nil {
+		return nil, err
+	}
+	cfg, setBy, ignored, err := decodeLayers(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	// Round-trip the normalized config through TOML to list its values by key.
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(normalize(cfg)); err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if _, err := toml.Decode(buf.String(), &values); err != nil {
+		return nil, err
+	}
+
+	sources := make([]Source, 0)
+	var walk func(prefix string, table map[string]any)
+	walk = func(prefix string, table map[string]any) {
+		for key, value := range table {
+			key = prefix + key
+			if nested, ok := value.(map[string]any); ok {
+				walk(key+".", nested)
+				continue
+			}
+			sources = append(sources, Source{Key: key, Value: formatValue(value), Path: setBy[key]})
+		}
+	}
+	walk("", values)
+	for _, source := range ignored {
+		source.Ignored = true
+		sources = append(sources, source)
+	}
+	slices.SortFunc(sources, func(a, b Source) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return sources, nil
+}
+
+func formatValue(value any) string {
+	switch value := value.(type) {
+	case string:
+		return strconv.Quote(value)
+	case []map[string]any:
+		if len(value) == 1 {
+			return "1 table"
+		}
+		return fmt.Sprintf("%d tables", len(value))
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,253 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/logs"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Returned when the connection to the server is gone.
+var ErrClosed = errors.New("connection to cadence closed")
+
+// How long a request waits for its reply.
+const replyTimeout = 5 * time.Second
+
+// Client for a machine served by another process.
+// It implements `pomodoro.Controller`, so the TUI can drive a daemon the same way it drives its own machine.
+type Client struct {
+	conn    net.Conn
+	mu      sync.Mutex
+	encoder *json.Encoder
+	events  chan pomodoro.Event
+	logger  logs.Logger
+
+	// Requests waiting for their reply, by ID. Guarded by `pendingMu`.
+	pendingMu sync.Mutex
+	pending   map[uint64]pendingReply
+	nextID    uint64
+	closed    bool
+}
+
+// Where the reader hands a reply.
+// With `forwardState`, the state in the reply is also put on the events channel first.
+type pendingReply struct {
+	replies      chan Message
+	forwardState bool
+}
+
+var _ pomodoro.Controller = (*Client)(nil)
+
+// Connect to the server listening on `path`.
+// Pass a logger to capture protocol problems when debugging.
+func Dial(path string, appLogger logs.Logger) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn, appLogger), nil
+}
+
+func newClient(conn net.Conn, appLogger logs.Logger) *Client {
+	c := &Client{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		events:  make(chan pomodoro.Event, 10),
+		logger:  appLogger,
+		pending: make(map[uint64]pendingReply),
+	}
+	go c.read()
+	return c
+}
+
+// Closes the connection. The events channel is closed once the reader stops.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Sends a request and waits for its reply.
+// A reply carrying an error is returned as an error.
+// A reply that arrives after the request timed out is dropped.
+func (c *Client) Send(req Request) (Message, error) {
+	return c.send(req, false)
+}
+
+func (c *Client) send(req Request, forwardState bool) (Message, error) {
+	replies, err := c.register(&req, forwardState)
+	if err != nil {
+		return Message{}, err
+	}
+	defer c.forget(req.ID)
+
+	c.mu.Lock()
+	err = c.encoder.Encode(req)
+	c.mu.Unlock()
+	if err != nil {
+		return Message{}, err
+	}
+
+	select {
+	case msg, ok := <-replies:
+		if !ok {
+			return Message{}, ErrClosed
+		}
+		if msg.Error != "" {
+			return msg, errors.New(msg.Error)
+		}
+		return msg, nil
+	case <-time.After(replyTimeout):
+		return Message{}, errors.New("timed out waiting for cadence to reply")
+	}
+}
+
+// Gives `req` a fresh ID and a channel for its reply.
+func (c *Client) register(req *Request, forwardState bool) (chan Message, error) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if c.closed {
+		return nil, ErrClosed
+	}
+	c.nextID++
+	req.ID = c.nextID
+	replies := make(chan Message, 1)
+	c.pending[req.ID] = pendingReply{replies: replies, forwardState: forwardState}
+	return replies, nil
+}
+
+func (c *Client) forget(id uint64) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	delete(c.pending, id)
+}
+
+// Hands a reply to the request waiting for it without ever blocking the reader.
+func (c *Client) deliver(msg Message) {
+	c.pendingMu.Lock()
+	pending, ok := c.pending[msg.ID]
+	delete(c.pending, msg.ID)
+	c.pendingMu.Unlock()
+
+	if !ok {
+		c.logf("control dropped reply to request %d", msg.ID)
+		return
+	}
+	if pending.forwardState && msg.Error == "" {
+		var state pomodoro.EventStateChanged
+		if err := json.Unmarshal(msg.Data, &state); err != nil {
+			c.logf("control decode failed: %v", err)
+		} else {
+			c.events <- state
+		}
+	}
+	// Buffered for exactly one reply, so this never blocks.
+	pending.replies <- msg
+}
+
+// Fetches the current state of the machine.
+func (c *Client) State() (pomodoro.EventStateChanged, error) {
+	msg, err := c.Send(Request{Command: CommandState})
+	if err != nil {
+		return pomodoro.EventStateChanged{}, err
+	}
+	var state pomodoro.EventStateChanged
+	if err := json.Unmarshal(msg.Data, &state); err != nil {
+		return pomodoro.EventStateChanged{}, err
+	}
+	return state, nil
+}
+
+// Subscribes to the machine's events.
+// The channel starts with the current state and closes when the connection does.
+func (c *Client) Subscribe() (<-chan pomodoro.Event, error) {
+	if _, err := c.Send(Request{Command: CommandSubscribe}); err != nil {
+		return nil, err
+	}
+	return c.events, nil
+}
+
+func (c *Client) Start() error     { return c.do(Request{Command: CommandStart}) }
+func (c *Client) Toggle() error    { return c.do(Request{Command: CommandToggle}) }
+func (c *Client) Pause() error     { return c.do(Request{Command: CommandPause}) }
+func (c *Client) Resume() error    { return c.do(Request{Command: CommandResume}) }
+func (c *Client) SkipBreak() error { return c.do(Request{Command: CommandSkipBreak}) }
+func (c *Client) Skip() error      { return c.do(Request{Command: CommandSkip}) }
+func (c *Client) Next() error      { return c.do(Request{Command: CommandNext}) }
+func (c *Client) Reset() error     { return c.do(Request{Command: CommandReset}) }
+
+func (c *Client) Extend(d time.Duration) error {
+	return c.do(Request{Command: CommandExtend, Seconds: wholeSeconds(d)})
+}
+
+func (c *Client) Shorten(d time.Duration) error {
+	return c.do(Request{Command: CommandShorten, Seconds: wholeSeconds(d)})
+}
+
+// The protocol counts in whole seconds, so a fraction of one rounds up rather than down to nothing.
+func wholeSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+func (c *Client) Restart(plan []pomodoro.PhaseDetail) error {
+	return c.do(Request{Command: CommandRestart, Plan: plan})
+}
+
+// Delivers the current state on the events channel, like `Machine.GetState` does for its subscribers.
+// The reader puts it there, in order with the other events and never after the channel is closed.
+func (c *Client) GetState() error {
+	_, err := c.send(Request{Command: CommandState}, true)
+	return err
+}
+
+func (c *Client) do(req Request) error {
+	_, err := c.Send(req)
+	return err
+}
+
+// Splits incoming lines into replies and events until the connection closes.
+func (c *Client) read() {
+	defer close(c.events)
+	defer c.closePending()
+
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			c.logf("control invalid message: %v", err)
+			continue
+		}
+		if msg.Type == TypeReply {
+			c.deliver(msg)
+			continue
+		}
+		event, err := DecodeEvent(msg)
+		if err != nil {
+			c.logf("control decode failed: %v", err)
+			continue
+		}
+		c.events <- event
+	}
+}
+
+// Fails every request still waiting, and any sent later, with `ErrClosed`.
+func (c *Client) closePending() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	c.closed = true
+	for id, pending := range c.pending {
+		delete(c.pending, id)
+		close(pending.replies)
+	}
+}
+
+func (c *Client) logf(format string, args ...any) {
+	if c.logger != nil {
+		c.logger.Printf(format, args...)
+	}
+}
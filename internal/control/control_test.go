@@ -0,0 +1,304 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Records the commands it receives and answers GetState on the events channel.
+// Commands fail with `err` when it is set.
+type fakeController struct {
+	mu     sync.Mutex
+	calls  []string
+	err    error
+	events chan pomodoro.Event
+	state  pomodoro.EventStateChanged
+}
+
+func newFakeController() *fakeController {
+	return &fakeController{
+		events: make(chan pomodoro.Event, 10),
+		state:  pomodoro.EventStateChanged{Status: pomodoro.StatusInit, Mode: pomodoro.ModePomodoro, WorkPhases: 4},
+	}
+}
+
+// Records a call and answers with the configured error.
+func (f *fakeController) record(call string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	return f.err
+}
+
+func (f *fakeController) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeController) Start() error                         { return f.record("start") }
+func (f *fakeController) Toggle() error                        { return f.record("toggle") }
+func (f *fakeController) Pause() error                         { return f.record("pause") }
+func (f *fakeController) Resume() error                        { return f.record("resume") }
+func (f *fakeController) SkipBreak() error                     { return f.record("skip_break") }
+func (f *fakeController) Skip() error                          { return f.record("skip") }
+func (f *fakeController) Next() error                          { return f.record("next") }
+func (f *fakeController) Extend(d time.Duration) error         { return f.record("extend " + d.String()) }
+func (f *fakeController) Shorten(d time.Duration) error        { return f.record("shorten " + d.String()) }
+func (f *fakeController) Reset() error                         { return f.record("reset") }
+func (f *fakeController) Restart([]pomodoro.PhaseDetail) error { return f.record("restart") }
+func (f *fakeController) GetState() error                      { f.events <- f.state; return nil }
+
+// Creates a hub over a fake machine and waits until it has seen the initial state.
+func newTestHub(t *testing.T) (*fakeController, *Hub) {
+	t.Helper()
+	controller := newFakeController()
+	hub := NewHub(controller, controller.events, nil)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, ok := hub.State(); ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("hub never received the initial state")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return controller, hub
+}
+
+func newTestServer(t *testing.T) (*fakeController, string) {
+	t.Helper()
+	controller, hub := newTestHub(t)
+	path := filepath.Join(t.TempDir(), "cadence.sock")
+	server, err := Listen(path, hub, nil)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return controller, path
+}
+
+func TestClientCommandsReachMachine(t *testing.T) {
+	controller, path := newTestServer(t)
+
+	client, err := Dial(path, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	client.Start()
+	client.Extend(5 * time.Minute)
+	client.Pause()
+
+	want := []string{"start", "extend 5m0s", "pause"}
+	got := controller.Calls()
+	if len(got) != len(want) {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", got, want)
+		}
+	}
+
+	state, err := client.State()
+	if err != nil {
+		t.Fatalf("state: %v", err)
+	}
+	if state.Status != pomodoro.StatusInit || state.WorkPhases != 4 {
+		t.Fatalf("state = %+v", state)
+	}
+
+	if _, err := client.Send(Request{Command: "bogus"}); err == nil {
+		t.Fatalf("expected an error for an unknown command")
+	}
+}
+
+func TestClientRoundsAdjustmentsUpToWholeSeconds(t *testing.T) {
+	controller, path := newTestServer(t)
+
+	client, err := Dial(path, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.Extend(500 * time.Millisecond); err != nil {
+		t.Fatalf("extend: %v", err)
+	}
+	if err := client.Shorten(90*time.Second + time.Millisecond); err != nil {
+		t.Fatalf("shorten: %v", err)
+	}
+	want := []string{"extend 1s", "shorten 1m31s"}
+	if got := controller.Calls(); len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestSubscribeStreamsEvents(t *testing.T) {
+	controller, path := newTestServer(t)
+
+	client, err := Dial(path, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+
+	events, err := client.Subscribe()
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// The current state comes first.
+	select {
+	case event := <-events:
+		if _, ok := event.(pomodoro.EventStateChanged); !ok {
+			t.Fatalf("first event = %T, want EventStateChanged", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for the initial state")
+	}
+
+	finished := pomodoro.EventPhaseFinished{
+		Phase:   pomodoro.PhaseSnapshot{Kind: pomodoro.PhaseWork, HumanIdx: 1, Duration: 25 * time.Minute},
+		Elapsed: 25 * time.Minute,
+	}
+	controller.events <- finished
+
+	select {
+	case event := <-events:
+		got, ok := event.(pomodoro.EventPhaseFinished)
+		if !ok {
+			t.Fatalf("event = %T, want EventPhaseFinished", event)
+		}
+		if got.Phase.Kind != pomodoro.PhaseWork || got.Elapsed != finished.Elapsed {
+			t.Fatalf("event = %+v, want %+v", got, finished)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for the phase event")
+	}
+}
+
+func TestListenRefusesRunningInstance(t *testing.T) {
+	_, path := newTestServer(t)
+	_, hub := newTestHub(t)
+
+	if _, err := Listen(path, hub, nil); err != ErrAlreadyRunning {
+		t.Fatalf("err = %v, want ErrAlreadyRunning", err)
+	}
+}
+
+func TestBindClaimsSocketBeforeServing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cadence.sock")
+	server, err := Bind(path, nil)
+	if err != nil {
+		t.Fatalf("bind: %v", err)
+	}
+	defer server.Close()
+
+	// A second instance backs out while the first is still setting up its machine.
+	if _, err := Bind(path, nil); err != ErrAlreadyRunning {
+		t.Fatalf("err = %v, want ErrAlreadyRunning", err)
+	}
+
+	// A client connecting before `Serve` is answered once it runs.
+	client, err := Dial(path, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+	controller, hub := newTestHub(t)
+	server.Serve(hub)
+	if err := client.Start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if calls := controller.Calls(); len(calls) != 1 || calls[0] != "start" {
+		t.Fatalf("calls = %v", calls)
+	}
+}
+
+func TestHubEndsStreamsWhenMachineStops(t *testing.T) {
+	controller, hub := newTestHub(t)
+	sub := hub.subscribe()
+
+	close(controller.events)
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-sub:
+			if !ok {
+				// Unsubscribing after the stream ended must not panic.
+				hub.unsubscribe(sub)
+				return
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for the stream to end")
+		}
+	}
+}
+
+func TestClientIgnoresStaleReplies(t *testing.T) {
+	server, conn := net.Pipe()
+	defer server.Close()
+	client := newClient(conn, nil)
+	defer client.Close()
+
+	go func() {
+		scanner := bufio.NewScanner(server)
+		encoder := json.NewEncoder(server)
+		for scanner.Scan() {
+			var req Request
+			if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+				return
+			}
+			// Replies to requests that already timed out come first and must not block the reader.
+			encoder.Encode(Message{Type: TypeReply, ID: req.ID + 100, Error: "stale"})
+			encoder.Encode(Message{Type: TypeReply, ID: req.ID + 200, Error: "stale"})
+			encoder.Encode(Message{Type: TypeReply, ID: req.ID, OK: true})
+		}
+	}()
+
+	for range 3 {
+		if err := client.Start(); err != nil {
+			t.Fatalf("expected each request to get its own reply, got %v", err)
+		}
+	}
+}
+
+func TestClientGetStateAfterClose(t *testing.T) {
+	_, path := newTestServer(t)
+
+	client, err := Dial(path, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	if err := client.GetState(); err != nil {
+		t.Fatalf("get state: %v", err)
+	}
+	select {
+	case event := <-client.events:
+		if _, ok := event.(pomodoro.EventStateChanged); !ok {
+			t.Fatalf("event = %T, want EventStateChanged", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for the state")
+	}
+
+	client.Close()
+	for range client.events {
+	}
+	// The events channel is closed by now; asking for the state again must fail rather than panic.
+	if err := client.GetState(); err != ErrClosed {
+		t.Fatalf("err = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,138 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Commands exposed as `POST /<command>`. Extend and shorten take the amount as `?seconds=N`.
+var httpCommands = []string{
+	CommandStart,
+	CommandToggle,
+	CommandPause,
+	CommandResume,
+	CommandSkip,
+	CommandSkipBreak,
+	CommandNext,
+	CommandExtend,
+	CommandShorten,
+	CommandReset,
+	CommandRestart,
+}
+
+// HTTP interface to a hub.
+//
+//   - `POST /<command>` runs a command and answers `{"ok":true}`, or `{"error":"..."}` with status 409 when the timer
+//     rejected the command and 400 when the request was malformed.
+//   - `GET /state` returns the latest `EventStateChanged`.
+//   - `GET /events` streams every event as Server-Sent Events named after the message types, starting with the current state.
+//
+// Commands sent by a browser from another site are refused with status 403, so a page the user happens to visit
+// cannot drive the timer. Clients that are not browsers, such as curl, are unaffected.
+func NewHTTPHandler(hub *Hub) http.Handler {
+	mux := http.NewServeMux()
+	for _, command := range httpCommands {
+		mux.HandleFunc("POST /"+command, func(w http.ResponseWriter, r *http.Request) {
+			handleCommand(hub, command, w, r)
+		})
+	}
+	mux.HandleFunc("GET /state", func(w http.ResponseWriter, r *http.Request) {
+		state, ok := hub.State()
+		if !ok {
+			writeError(w, http.StatusServiceUnavailable, errStateUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusOK, state)
+	})
+	mux.HandleFunc("GET /events", func(w http.ResponseWriter, r *http.Request) {
+		handleEvents(hub, w, r)
+	})
+
+	protection := http.NewCrossOriginProtection()
+	protection.SetDenyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusForbidden, errors.New("cross-origin request refused"))
+	}))
+	return protection.Handler(mux)
+}
+
+func handleCommand(hub *Hub, command string, w http.ResponseWriter, r *http.Request) {
+	req := Request{Command: command}
+	if command == CommandExtend || command == CommandShorten {
+		seconds, err := strconv.Atoi(r.URL.Query().Get("seconds"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%s needs ?seconds=N", command))
+			return
+		}
+		req.Seconds = seconds
+	}
+	if command == CommandRestart && r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid plan: %w", err))
+			return
+		}
+		req.Command = command
+	}
+
+	if _, err := hub.dispatch(req); err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Message{OK: true})
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, pomodoro.ErrInvalidState):
+		return http.StatusConflict
+	case errors.Is(err, pomodoro.ErrMachineStopped), errors.Is(err, errStateUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func handleEvents(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sub := hub.subscribe()
+	defer hub.unsubscribe(sub)
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.Type, msg.Data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Message{Error: err.Error()})
+}
@@ -0,0 +1,132 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+func TestHTTPCommandsAndState(t *testing.T) {
+	controller, hub := newTestHub(t)
+	handler := NewHTTPHandler(hub)
+
+	for _, target := range []string{"/start", "/extend?seconds=60"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("POST %s = %d %s", target, rec.Code, rec.Body)
+		}
+	}
+	calls := controller.Calls()
+	if len(calls) != 2 || calls[0] != "start" || calls[1] != "extend 1m0s" {
+		t.Fatalf("calls = %v", calls)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/shorten", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("POST /shorten without seconds = %d, want 400", rec.Code)
+	}
+
+	controller.mu.Lock()
+	controller.err = fmt.Errorf("%w: cannot pause while init", pomodoro.ErrInvalidState)
+	controller.mu.Unlock()
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pause", nil))
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "cannot pause") {
+		t.Fatalf("POST /pause rejected by the machine = %d %s, want 409", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/state", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /state = %d", rec.Code)
+	}
+	var state pomodoro.EventStateChanged
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("decode state: %v", err)
+	}
+	if state.WorkPhases != 4 || state.Status != pomodoro.StatusInit {
+		t.Fatalf("state = %+v", state)
+	}
+}
+
+func TestHTTPRefusesCrossOriginCommands(t *testing.T) {
+	controller, hub := newTestHub(t)
+	handler := NewHTTPHandler(hub)
+
+	crossSite := httptest.NewRequest(http.MethodPost, "http://localhost:8080/start", nil)
+	crossSite.Header.Set("Sec-Fetch-Site", "cross-site")
+	otherOrigin := httptest.NewRequest(http.MethodPost, "http://localhost:8080/start", nil)
+	otherOrigin.Header.Set("Origin", "https://evil.example")
+	for _, req := range []*http.Request{crossSite, otherOrigin} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("cross-origin POST /start = %d %s, want 403", rec.Code, rec.Body)
+		}
+	}
+	if calls := controller.Calls(); len(calls) != 0 {
+		t.Fatalf("calls = %v, want none", calls)
+	}
+
+	sameOrigin := httptest.NewRequest(http.MethodPost, "http://localhost:8080/start", nil)
+	sameOrigin.Header.Set("Sec-Fetch-Site", "same-origin")
+	sameOrigin.Header.Set("Origin", "http://localhost:8080")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, sameOrigin)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("same-origin POST /start = %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestHTTPEventsStream(t *testing.T) {
+	controller, hub := newTestHub(t)
+	server := httptest.NewServer(NewHTTPHandler(hub))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events: %v", err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("content type = %q", got)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "event: ") {
+				lines <- strings.TrimPrefix(line, "event: ")
+			}
+		}
+		close(lines)
+	}()
+
+	next := func() string {
+		select {
+		case line := <-lines:
+			return line
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for an event")
+			return ""
+		}
+	}
+
+	if got := next(); got != TypeStateChanged {
+		t.Fatalf("first event = %q, want %q", got, TypeStateChanged)
+	}
+	controller.events <- pomodoro.EventTimerFinished{}
+	if got := next(); got != TypeTimerFinished {
+		t.Fatalf("event = %q, want %q", got, TypeTimerFinished)
+	}
+}
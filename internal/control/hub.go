@@ -0,0 +1,175 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/logs"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Shares a machine with remote clients, whether they come over the socket or HTTP.
+// The hub keeps the latest state from the machine's events so "state" can answer right away,
+// and fans events out to subscribed clients.
+type Hub struct {
+	machine     pomodoro.Controller
+	logger      logs.Logger
+	mu          sync.Mutex
+	state       *pomodoro.EventStateChanged
+	subscribers map[chan Message]struct{}
+	stopped     bool
+}
+
+// Create a hub for `machine`, which must already be running.
+// Pass a dedicated subscription in `events`; it feeds the state cache and the subscribed clients.
+func NewHub(machine pomodoro.Controller, events <-chan pomodoro.Event, appLogger logs.Logger) *Hub {
+	h := &Hub{
+		machine:     machine,
+		logger:      appLogger,
+		subscribers: make(map[chan Message]struct{}),
+	}
+	go h.watch(events)
+	if err := machine.GetState(); err != nil {
+		h.logf("control initial state failed: %v", err)
+	}
+	return h
+}
+
+// Latest state seen from the machine, if any.
+func (h *Hub) State() (pomodoro.EventStateChanged, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.state == nil {
+		return pomodoro.EventStateChanged{}, false
+	}
+	return *h.state, true
+}
+
+func (h *Hub) watch(events <-chan pomodoro.Event) {
+	for event := range events {
+		msg, err := EncodeEvent(event)
+		if err != nil {
+			h.logf("control encode failed: %v", err)
+			continue
+		}
+
+		h.mu.Lock()
+		if state, ok := event.(pomodoro.EventStateChanged); ok {
+			h.state = &state
+		}
+		for ch := range h.subscribers {
+			select {
+			case ch <- msg:
+				// Sent
+			default:
+				// Client is too slow; drop the event to avoid blocking the others.
+				h.logf("control dropped event: %s", msg.Type)
+			}
+		}
+		h.mu.Unlock()
+	}
+
+	// The machine stopped, so let every client know by ending its stream.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopped = true
+	for ch := range h.subscribers {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+// Runs a single command against the machine.
+// The state command returns the state as JSON; the other commands return no data.
+// Subscriptions are handled by each transport, so "subscribe" is not accepted here.
+func (h *Hub) dispatch(req Request) (json.RawMessage, error) {
+	switch req.Command {
+	case CommandStart:
+		return nil, h.machine.Start()
+	case CommandToggle:
+		return nil, h.machine.Toggle()
+	case CommandPause:
+		return nil, h.machine.Pause()
+	case CommandResume:
+		return nil, h.machine.Resume()
+	case CommandSkip:
+		return nil, h.machine.Skip()
+	case CommandSkipBreak:
+		return nil, h.machine.SkipBreak()
+	case CommandNext:
+		return nil, h.machine.Next()
+	case CommandExtend, CommandShorten:
+		if req.Seconds <= 0 {
+			return nil, fmt.Errorf("%w: %s needs a positive number of seconds", errBadRequest, req.Command)
+		}
+		d := time.Duration(req.Seconds) * time.Second
+		if req.Command == CommandExtend {
+			return nil, h.machine.Extend(d)
+		}
+		return nil, h.machine.Shorten(d)
+	case CommandReset:
+		return nil, h.machine.Reset()
+	case CommandRestart:
+		return nil, h.machine.Restart(req.Plan)
+	case CommandState:
+		state, ok := h.State()
+		if !ok {
+			return nil, errStateUnavailable
+		}
+		return json.Marshal(state)
+	default:
+		return nil, fmt.Errorf("%w: unknown command %q", errBadRequest, req.Command)
+	}
+}
+
+// Returned for requests that could never succeed, as opposed to commands the timer rejected.
+var errBadRequest = errors.New("bad request")
+
+var errStateUnavailable = errors.New("state not available yet")
+
+// Register a client for events. The latest state is queued first so the client can render right away.
+func (h *Hub) subscribe() chan Message {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Message, 10)
+	if h.state != nil {
+		if msg, err := EncodeEvent(*h.state); err == nil {
+			ch <- msg
+		}
+	}
+	if h.stopped {
+		close(ch)
+		return ch
+	}
+	h.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (h *Hub) unsubscribe(ch chan Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// The stream may already have ended with the machine.
+	if _, ok := h.subscribers[ch]; ok {
+		delete(h.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (h *Hub) logf(format string, args ...any) {
+	if h.logger != nil {
+		h.logger.Printf(format, args...)
+	}
+}
+
+func reply(data json.RawMessage, err error) Message {
+	if err != nil {
+		return Message{Type: TypeReply, Error: err.Error()}
+	}
+	return Message{Type: TypeReply, OK: true, Data: data}
+}
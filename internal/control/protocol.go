@@ -0,0 +1,112 @@
+// Package control exposes a machine over a Unix socket so other processes can drive it.
+//
+// The protocol is line-delimited JSON. Clients send one `Request` per line and the server answers each
+// with a `Message` of type "reply", echoing the request's `ID` so late replies can be told apart. After "subscribe", machine events are streamed on the same connection
+// as messages typed after the event, interleaved with replies.
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Commands understood by the server.
+const (
+	CommandStart     = "start"
+	CommandToggle    = "toggle"
+	CommandPause     = "pause"
+	CommandResume    = "resume"
+	CommandSkip      = "skip"
+	CommandSkipBreak = "skip_break"
+	CommandNext      = "next"
+	CommandExtend    = "extend"
+	CommandShorten   = "shorten"
+	CommandReset     = "reset"
+	CommandRestart   = "restart"
+	CommandState     = "state"
+	CommandSubscribe = "subscribe"
+)
+
+// Message types sent by the server.
+const (
+	TypeReply         = "reply"
+	TypeStateChanged  = "state_changed"
+	TypePhaseFinished = "phase_finished"
+	TypePhaseOvertime = "phase_overtime"
+	TypeTimerFinished = "timer_finished"
+)
+
+// A single command sent by a client.
+// `Seconds` is used by extend and shorten; `Plan` optionally replaces the plan on restart.
+// `ID` is optional and echoed in the reply.
+type Request struct {
+	ID      uint64                 `json:"id,omitempty"`
+	Command string                 `json:"command"`
+	Seconds int                    `json:"seconds,omitempty"`
+	Plan    []pomodoro.PhaseDetail `json:"plan,omitempty"`
+}
+
+// A single line sent by the server.
+// Replies carry `OK` or an `Error` and the `ID` of their request; the state command replies with the state in `Data`.
+// Events carry the JSON encoded event in `Data`.
+type Message struct {
+	Type  string          `json:"type,omitempty"`
+	ID    uint64          `json:"id,omitempty"`
+	OK    bool            `json:"ok,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Data  json.RawMessage `json:"data,omitempty"`
+}
+
+// Encode a machine event as a message.
+func EncodeEvent(event pomodoro.Event) (Message, error) {
+	var kind string
+	switch event.(type) {
+	case pomodoro.EventStateChanged:
+		kind = TypeStateChanged
+	case pomodoro.EventPhaseFinished:
+		kind = TypePhaseFinished
+	case pomodoro.EventPhaseOvertime:
+		kind = TypePhaseOvertime
+	case pomodoro.EventTimerFinished:
+		kind = TypeTimerFinished
+	default:
+		return Message{}, fmt.Errorf("unknown event %T", event)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Type: kind, Data: data}, nil
+}
+
+// Decode a message back into the machine event it carries.
+func DecodeEvent(msg Message) (pomodoro.Event, error) {
+	switch msg.Type {
+	case TypeStateChanged:
+		var event pomodoro.EventStateChanged
+		return event, json.Unmarshal(msg.Data, &event)
+	case TypePhaseFinished:
+		var event pomodoro.EventPhaseFinished
+		return event, json.Unmarshal(msg.Data, &event)
+	case TypePhaseOvertime:
+		var event pomodoro.EventPhaseOvertime
+		return event, json.Unmarshal(msg.Data, &event)
+	case TypeTimerFinished:
+		return pomodoro.EventTimerFinished{}, nil
+	default:
+		return nil, fmt.Errorf("unknown message type %q", msg.Type)
+	}
+}
+
+// Location of the control socket.
+// Follows the XDG base directory spec, falling back to a per-user name in the temp directory.
+func SocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "cadence.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("cadence-%d.sock", os.Getuid()))
+}
@@ -0,0 +1,147 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/diegoserranor/cadence/internal/logs"
+)
+
+// Returned by `Listen` when another instance already serves the socket.
+var ErrAlreadyRunning = errors.New("cadence is already running")
+
+// Serves a hub on a Unix socket.
+type Server struct {
+	hub      *Hub
+	listener net.Listener
+	path     string
+	logger   logs.Logger
+}
+
+// Start serving `hub` on the socket at `path`.
+// A stale socket left behind by a crashed instance is replaced.
+func Listen(path string, hub *Hub, appLogger logs.Logger) (*Server, error) {
+	s, err := Bind(path, appLogger)
+	if err != nil {
+		return nil, err
+	}
+	s.Serve(hub)
+	return s, nil
+}
+
+// Claim the socket at `path` without answering on it yet, so a second instance can back out
+// before it builds a machine of its own. Clients that connect in the meantime wait for `Serve`.
+// A stale socket left behind by a crashed instance is replaced.
+func Bind(path string, appLogger logs.Logger) (*Server, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, ErrAlreadyRunning
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &Server{listener: listener, path: path, logger: appLogger}, nil
+}
+
+// Start answering clients with `hub`. Call it once.
+func (s *Server) Serve(hub *Hub) {
+	s.hub = hub
+	go s.accept()
+}
+
+// Stops accepting connections and removes the socket.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	if rmErr := os.Remove(s.path); rmErr != nil && !errors.Is(rmErr, os.ErrNotExist) && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) && s.logger != nil {
+				s.logger.Printf("control accept failed: %v", err)
+			}
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+// Answers requests from a single client until it disconnects.
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var writeMu sync.Mutex
+	encoder := json.NewEncoder(conn)
+	send := func(msg Message) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return encoder.Encode(msg)
+	}
+
+	var sub chan Message
+	defer func() {
+		if sub != nil {
+			s.hub.unsubscribe(sub)
+		}
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			send(reply(nil, fmt.Errorf("invalid request: %w", err)))
+			continue
+		}
+
+		if req.Command == CommandSubscribe {
+			if err := send(withID(reply(nil, nil), req.ID)); err != nil {
+				return
+			}
+			if sub == nil {
+				sub = s.hub.subscribe()
+				go func() {
+					for msg := range sub {
+						if err := send(msg); err != nil {
+							return
+						}
+					}
+				}()
+			}
+			continue
+		}
+
+		if err := send(withID(reply(s.hub.dispatch(req)), req.ID)); err != nil {
+			return
+		}
+	}
+}
+
+func withID(msg Message, id uint64) Message {
+	msg.ID = id
+	return msg
+}
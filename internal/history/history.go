@@ -0,0 +1,212 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+	"github.com/diegoserranor/cadence/internal/xdg"
+)
+
+// A completed phase as written to the history log.
+type Record struct {
+	Kind      pomodoro.PhaseKind `json:"kind"`
+	Label     string             `json:"label,omitempty"`
+	Planned   time.Duration      `json:"planned"`
+	Actual    time.Duration      `json:"actual"`
+	Overtime  time.Duration      `json:"overtime,omitempty"`
+	Skipped   bool               `json:"skipped"`
+	Tags      []string           `json:"tags,omitempty"`
+	Task      string             `json:"task,omitempty"`
+	Note      string             `json:"note,omitempty"`
+	StartedAt time.Time          `json:"started_at"`
+	EndedAt   time.Time          `json:"ended_at"`
+}
+
+// Build a record from a phase finished event.
+func RecordFromEvent(event pomodoro.EventPhaseFinished) Record {
+	return Record{
+		Kind:      event.Phase.Kind,
+		Label:     event.Phase.Label,
+		Planned:   event.Phase.Duration,
+		Actual:    event.Elapsed,
+		Overtime:  event.Overtime,
+		Skipped:   event.Skipped,
+		StartedAt: event.StartedAt,
+		EndedAt:   event.EndedAt,
+	}
+}
+
+// Split tags typed as free text, separated by commas or spaces. A leading `#` is dropped and duplicates are removed.
+func ParseTags(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	tags := make([]string, 0, len(fields))
+	for _, field := range fields {
+		tag := strings.TrimPrefix(field, "#")
+		if tag == "" || slices.Contains(tags, tag) {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// Returned by `Annotate` when no record matches the phase.
+var ErrRecordNotFound = errors.New("history record not found")
+
+// Append-only JSONL log of completed phases, one record per line.
+// Tags and notes added later go on lines of their own; see `Annotate`.
+type Store struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Location of the history log under the user's data directory.
+func Path() (string, error) {
+	dir, err := xdg.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cadence", "history.jsonl"), nil
+}
+
+func (s *Store) Append(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLine(record)
+}
+
+// Write `v` as one line at the end of the log. Appends are atomic, so other stores and processes can write too.
+func (s *Store) appendLine(v any) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+// Read every record in the order it was written, with its latest annotation applied.
+// A missing log is an empty history. Lines that fail to parse are skipped.
+func (s *Store) Load() ([]Record, error) {
+	return s.LoadSince(time.Time{})
+}
+
+// Read the records of phases that ended at or after `since`.
+func (s *Store) LoadSince(since time.Time) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(since)
+}
+
+func (s *Store) load(since time.Time) ([]Record, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	records := make([]Record, 0)
+	// Index of the latest record for each phase, so annotations can find it.
+	byPhase := make(map[phaseKey]int)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line logLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			continue
+		}
+		if line.EndedAt.Before(since) {
+			continue
+		}
+		key := keyOf(line.StartedAt, line.EndedAt)
+		if line.Type == lineAnnotation {
+			if i, ok := byPhase[key]; ok {
+				records[i].Tags = line.Tags
+				records[i].Note = line.Note
+			}
+			continue
+		}
+		byPhase[key] = len(records)
+		records = append(records, line.Record)
+	}
+	return records, scanner.Err()
+}
+
+// Set the tags and note of the record for the phase that started at `startedAt` and ended at `endedAt`.
+// Tags and the note replace any the record already had.
+// The log is never rewritten: an annotation line is appended and applied to the record when the log is read.
+func (s *Store) Annotate(startedAt, endedAt time.Time, tags []string, note string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records, err := s.load(endedAt)
+	if err != nil {
+		return err
+	}
+	found := slices.ContainsFunc(records, func(record Record) bool {
+		return record.StartedAt.Equal(startedAt) && record.EndedAt.Equal(endedAt)
+	})
+	if !found {
+		return fmt.Errorf("%w: phase ended at %s", ErrRecordNotFound, endedAt.Format(time.RFC3339))
+	}
+
+	return s.appendLine(annotation{Type: lineAnnotation, Tags: tags, Note: note, StartedAt: startedAt, EndedAt: endedAt})
+}
+
+// Marks annotation lines. Record lines have no type.
+const lineAnnotation = "annotation"
+
+// Tags and note for the phase that started and ended at the given times, written after its record.
+type annotation struct {
+	Type      string    `json:"type"`
+	Tags      []string  `json:"tags,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+}
+
+// Either kind of line. Annotations share their fields with records.
+type logLine struct {
+	Type string `json:"type,omitempty"`
+	Record
+}
+
+// Identifies a phase by when it started and ended.
+type phaseKey struct {
+	startedAt int64
+	endedAt   int64
+}
+
+func keyOf(startedAt, endedAt time.Time) phaseKey {
+	return phaseKey{startedAt: startedAt.UnixNano(), endedAt: endedAt.UnixNano()}
+}
@@ -0,0 +1,130 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+func TestStoreAppendsAndLoadsRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := NewStore(path)
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Kind: pomodoro.PhaseWork, Planned: 25 * time.Minute, Actual: 25 * time.Minute, StartedAt: start, EndedAt: start.Add(25 * time.Minute)},
+		{Kind: pomodoro.PhaseWork, Planned: 25 * time.Minute, Actual: 20 * time.Minute, Skipped: true, StartedAt: start.Add(24 * time.Hour), EndedAt: start.Add(24*time.Hour + 20*time.Minute)},
+	}
+	for _, record := range records {
+		if err := store.Append(record); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(loaded) != 2 || loaded[1].Actual != 20*time.Minute || !loaded[1].Skipped {
+		t.Fatalf("expected both records back in order, got %+v", loaded)
+	}
+
+	recent, err := store.LoadSince(start.Add(24 * time.Hour))
+	if err != nil {
+		t.Fatalf("load since failed: %v", err)
+	}
+	if len(recent) != 1 || !recent[0].StartedAt.Equal(records[1].StartedAt) {
+		t.Fatalf("expected only the second record, got %+v", recent)
+	}
+}
+
+func TestStoreSkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	if err := os.WriteFile(path, []byte("{\"kind\":\"Work\"}\nnot json\n"), 0o644); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	loaded, err := NewStore(path).Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("expected 1 valid record, got %d", len(loaded))
+	}
+}
+
+func TestMissingStoreIsEmpty(t *testing.T) {
+	loaded, err := NewStore(filepath.Join(t.TempDir(), "missing.jsonl")).Load()
+	if err != nil || len(loaded) != 0 {
+		t.Fatalf("expected an empty history, got %v %v", loaded, err)
+	}
+}
+
+func TestStoreAnnotatesRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := NewStore(path)
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	for i := range 2 {
+		started := start.Add(time.Duration(i) * time.Hour)
+		record := Record{Kind: pomodoro.PhaseWork, Actual: 25 * time.Minute, StartedAt: started, EndedAt: started.Add(25 * time.Minute)}
+		if err := store.Append(record); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	tags := ParseTags("#design, review design")
+	if err := store.Annotate(start, start.Add(25*time.Minute), tags, "Sketched the layout"); err != nil {
+		t.Fatalf("annotate failed: %v", err)
+	}
+	if err := store.Annotate(start, start, nil, "no such phase"); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("expected a missing record error, got %v", err)
+	}
+
+	loaded, err := store.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Note != "Sketched the layout" || len(loaded[0].Tags) != 2 || loaded[0].Tags[1] != "review" {
+		t.Fatalf("expected the first record annotated, got %+v", loaded)
+	}
+	if loaded[1].Note != "" || loaded[1].Tags != nil {
+		t.Fatalf("expected the second record untouched, got %+v", loaded[1])
+	}
+}
+
+func TestAnnotateAppendsAlongsideOtherStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	daemon, tui := NewStore(path), NewStore(path)
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	first := Record{Kind: pomodoro.PhaseWork, StartedAt: start, EndedAt: start.Add(25 * time.Minute)}
+	second := Record{Kind: pomodoro.PhaseBreak, StartedAt: first.EndedAt, EndedAt: first.EndedAt.Add(5 * time.Minute)}
+
+	if err := daemon.Append(first); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := tui.Annotate(first.StartedAt, first.EndedAt, []string{"draft"}, "First pass"); err != nil {
+		t.Fatalf("annotate failed: %v", err)
+	}
+	if err := daemon.Append(second); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	// A later annotation replaces the earlier one.
+	if err := tui.Annotate(first.StartedAt, first.EndedAt, []string{"design"}, ""); err != nil {
+		t.Fatalf("annotate failed: %v", err)
+	}
+
+	loaded, err := daemon.Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(loaded) != 2 || loaded[1].Kind != pomodoro.PhaseBreak {
+		t.Fatalf("expected both records, got %+v", loaded)
+	}
+	if loaded[0].Note != "" || len(loaded[0].Tags) != 1 || loaded[0].Tags[0] != "design" {
+		t.Fatalf("expected the latest annotation, got %+v", loaded[0])
+	}
+}
@@ -0,0 +1,26 @@
+package history
+
+import (
+	"github.com/diegoserranor/cadence/internal/logs"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Records every finished phase from the machine's events in the store.
+// Work phases are attributed to the task named by `activeTask`, which may be nil when tasks are not tracked.
+func Run(events <-chan pomodoro.Event, store *Store, activeTask func() string, appLogger logs.Logger) {
+	go func() {
+		for event := range events {
+			finished, ok := event.(pomodoro.EventPhaseFinished)
+			if !ok {
+				continue
+			}
+			record := RecordFromEvent(finished)
+			if activeTask != nil && record.Kind == pomodoro.PhaseWork {
+				record.Task = activeTask()
+			}
+			if err := store.Append(record); err != nil && appLogger != nil {
+				appLogger.Printf("history append failed: %v", err)
+			}
+		}
+	}()
+}
@@ -2,6 +2,7 @@ package notify
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/diegoserranor/cadence/internal/pomodoro"
 	"github.com/gen2brain/beeep"
@@ -11,10 +12,25 @@ const AppName = "Cadence"
 
 func Run(events <-chan pomodoro.Event) {
 	go func() {
+		// A finished phase is announced with the state that follows it, which tells how long the next phase is.
+		var finished *pomodoro.EventPhaseFinished
 		for event := range events {
 			switch event := event.(type) {
 			case pomodoro.EventPhaseFinished:
-				notifyPhaseFinished(event)
+				// Phases that went into overtime were already announced,
+				// and the timer finished notification covers the final phase.
+				// A phase ended early was ended by the user, who is looking at the timer already.
+				if event.Overtime > 0 || event.Final || event.Skipped {
+					continue
+				}
+				finished = &event
+			case pomodoro.EventStateChanged:
+				if finished != nil {
+					notifyPhaseFinished(*finished, event.Phase)
+					finished = nil
+				}
+			case pomodoro.EventPhaseOvertime:
+				notifyPhaseOvertime(event)
 			case pomodoro.EventTimerFinished:
 				notifyTimerFinished()
 			}
@@ -22,17 +38,26 @@ func Run(events <-chan pomodoro.Event) {
 	}()
 }
 
-func notifyPhaseFinished(event pomodoro.EventPhaseFinished) {
+func notifyPhaseFinished(event pomodoro.EventPhaseFinished, next pomodoro.PhaseSnapshot) {
 	var text string
-	if event.Phase.Kind == pomodoro.PhaseWork {
-		text = "Take 5"
-	} else {
+	switch {
+	case next.Kind.IsBreak():
+		// Flowtime breaks are a share of the work time, so say how long this one is.
+		text = fmt.Sprintf("Take %d", max(int(next.Duration.Round(time.Minute)/time.Minute), 1))
+	case event.Phase.Kind == pomodoro.PhaseLongBreak:
+		text = "Rested? Time to grind"
+	default:
 		text = "Time to grind"
 	}
 	title := fmt.Sprintf("%s %d finished", event.Phase.Kind, event.Phase.HumanIdx)
 	notify(title, text)
 }
 
+func notifyPhaseOvertime(event pomodoro.EventPhaseOvertime) {
+	title := fmt.Sprintf("%s %d is up", event.Phase.Kind, event.Phase.HumanIdx)
+	notify(title, "Counting overtime until you move on")
+}
+
 func notifyTimerFinished() {
 	notify("Timer finished", "Nice job")
 }
@@ -0,0 +1,46 @@
+package pomodoro
+
+import "time"
+
+// Source of time for the machine.
+// Inject a fake implementation with `WithClock` to drive the timer without sleeping.
+type Clock interface {
+	// Current time, including a monotonic reading when the clock has one.
+	Now() time.Time
+	// Current wall clock time, which keeps advancing while the system sleeps.
+	Wall() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Delivers ticks on `C` until stopped. See `time.Ticker`.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Strips monotonic component so elapsed uses wall clock (continues during sleep).
+func (realClock) Wall() time.Time {
+	return time.Now().Round(0)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{ticker: time.NewTicker(d)}
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
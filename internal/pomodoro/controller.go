@@ -0,0 +1,24 @@
+package pomodoro
+
+import "time"
+
+// Commands that drive a timer.
+// `*Machine` implements it in process; remote clients implement it for a machine owned by another process.
+// Each method returns once the command was applied, with `ErrInvalidState` when the command does not fit the timer's status.
+// The resulting state still arrives as events.
+type Controller interface {
+	Start() error
+	Toggle() error
+	Pause() error
+	Resume() error
+	SkipBreak() error
+	Skip() error
+	Next() error
+	Extend(d time.Duration) error
+	Shorten(d time.Duration) error
+	Reset() error
+	Restart(plan []PhaseDetail) error
+	GetState() error
+}
+
+var _ Controller = (*Machine)(nil)
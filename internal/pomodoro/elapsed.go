@@ -10,9 +10,9 @@ const (
 // Determines elapsed time. It chooses monotonic elapsed time by default.
 // It switches to wall clock when drift suggests a sleep.
 // Negative wall deltas get clamped, and an optional cap can be applied to avoid huge jumps.
-func elapsedSinceLastTick(lastTick time.Time, lastWall time.Time) time.Duration {
-	monoElapsed := time.Since(lastTick)
-	wallElapsed := max(time.Since(lastWall), 0)
+func elapsedSinceLastTick(clock Clock, lastTick time.Time, lastWall time.Time) time.Duration {
+	monoElapsed := clock.Now().Sub(lastTick)
+	wallElapsed := max(clock.Wall().Sub(lastWall), 0)
 
 	drift := max(wallElapsed-monoElapsed, 0)
 	elapsed := monoElapsed
@@ -21,8 +21,3 @@ func elapsedSinceLastTick(lastTick time.Time, lastWall time.Time) time.Duration
 	}
 	return elapsed
 }
-
-// Strips monotonic component so elapsed uses wall clock (continues during sleep).
-func nowWallClock() time.Time {
-	return time.Now().Round(0)
-}
@@ -0,0 +1,91 @@
+package pomodoro
+
+import (
+	"sync"
+	"time"
+)
+
+// Manually driven clock for tests.
+// `Advance` moves both readings forward and fires due tickers.
+// `Sleep` moves only the wall clock, like a suspended system.
+type fakeClock struct {
+	mu      sync.Mutex
+	mono    time.Time
+	wall    time.Time
+	tickers []*fakeTicker
+}
+
+func newFakeClock() *fakeClock {
+	start := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	return &fakeClock{mono: start, wall: start}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.mono
+}
+
+func (c *fakeClock) Wall() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.wall
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{ch: make(chan time.Time, 1), period: d, next: c.mono.Add(d)}
+	c.tickers = append(c.tickers, t)
+	return t
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.mono = c.mono.Add(d)
+	c.wall = c.wall.Add(d)
+	for _, t := range c.tickers {
+		t.fire(c.mono)
+	}
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.wall = c.wall.Add(d)
+}
+
+type fakeTicker struct {
+	mu      sync.Mutex
+	ch      chan time.Time
+	period  time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+}
+
+// Like `time.Ticker`, at most one tick is buffered and missed ticks are dropped.
+func (t *fakeTicker) fire(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped || now.Before(t.next) {
+		return
+	}
+	select {
+	case t.ch <- now:
+	default:
+	}
+	for !now.Before(t.next) {
+		t.next = t.next.Add(t.period)
+	}
+}
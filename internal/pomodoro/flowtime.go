@@ -0,0 +1,334 @@
+package pomodoro
+
+import (
+	"time"
+)
+
+// Flowtime processor.
+// Work phases count up until the user ends them, then a break proportional to the work time counts down.
+// Work and breaks alternate until the timer is reset; there is no fixed number of phases.
+type flowtime struct {
+	breakRatio    float64
+	phaseIdx      int
+	phaseElapsed  time.Duration
+	phaseAdjust   time.Duration
+	breakDur      time.Duration
+	phaseLastTick time.Time
+	phaseLastWall time.Time
+	phaseStarted  time.Time
+	status        TimerStatus
+	clock         Clock
+}
+
+func newFlowtime(breakRatio float64) *flowtime {
+	return &flowtime{
+		breakRatio: breakRatio,
+		phaseIdx:   0,
+		status:     StatusInit,
+		clock:      realClock{},
+	}
+}
+
+func (f *flowtime) apply(cmd command) transition {
+	before := f.snapshot()
+	after := before
+	delta := advanceDelta{}
+	emitState := false
+
+	switch cmd.kind {
+	case commandStart:
+		if f.start() {
+			emitState = true
+		}
+	case commandPause:
+		var paused bool
+		delta, paused = f.pause()
+		if paused {
+			emitState = true
+		}
+	case commandResume:
+		if f.resume() {
+			emitState = true
+		}
+	case commandSkip:
+		var skipped bool
+		delta, skipped = f.skip()
+		if skipped {
+			emitState = true
+		}
+	case commandSkipBreak:
+		if f.kind().IsBreak() {
+			var skipped bool
+			delta, skipped = f.skip()
+			if skipped {
+				emitState = true
+			}
+		}
+	case commandAdjust:
+		var adjusted bool
+		delta, adjusted = f.adjust(cmd.duration)
+		if adjusted {
+			emitState = true
+		}
+	case commandGetState:
+		emitState = true
+	case commandReset:
+		if f.reset() {
+			emitState = true
+		}
+	case commandRestart:
+		// Flowtime has no plan, so a new plan is ignored.
+		f.reset()
+		if f.start() {
+			emitState = true
+		}
+	case commandNext:
+		// Flowtime phases never go into overtime.
+	}
+
+	if emitState || len(delta.completions) > 0 {
+		after = f.snapshot()
+	}
+
+	return transition{
+		From:        before,
+		To:          after,
+		Completions: delta.completions,
+		EmitState:   emitState,
+		Err:         commandError(cmd.kind, before.Status, emitState),
+	}
+}
+
+func (f *flowtime) tick() transition {
+	before := f.snapshot()
+	if f.status != StatusRunning {
+		return transition{
+			From:      before,
+			To:        before,
+			EmitState: false,
+		}
+	}
+
+	delta := f.catchUp()
+	after := f.snapshot()
+	return transition{
+		From:        before,
+		To:          after,
+		Completions: delta.completions,
+		EmitState:   true,
+	}
+}
+
+func (f *flowtime) start() bool {
+	if f.status == StatusInit {
+		f.phaseElapsed = 0
+		f.phaseLastTick = f.clock.Now()
+		f.phaseLastWall = f.clock.Wall()
+		f.phaseStarted = f.phaseLastWall
+		f.status = StatusRunning
+		return true
+	}
+	return false
+}
+
+func (f *flowtime) pause() (advanceDelta, bool) {
+	if f.status == StatusRunning {
+		delta := f.catchUp()
+		f.status = StatusPaused
+		return delta, true
+	}
+	return advanceDelta{}, false
+}
+
+func (f *flowtime) resume() bool {
+	if f.status == StatusPaused {
+		f.phaseLastTick = f.clock.Now()
+		f.phaseLastWall = f.clock.Wall()
+		f.status = StatusRunning
+		return true
+	}
+	return false
+}
+
+// Ends the current phase.
+// Ending work is the normal way to move on and starts the proportional break.
+// Ending a break early is recorded as a skip.
+func (f *flowtime) skip() (advanceDelta, bool) {
+	if f.status != StatusRunning && f.status != StatusPaused {
+		return advanceDelta{}, false
+	}
+
+	idx := f.phaseIdx
+	delta := advanceDelta{}
+	if f.status == StatusRunning {
+		delta = f.catchUp()
+		if f.phaseIdx != idx {
+			return delta, true
+		}
+	}
+
+	now := f.clock.Wall()
+	if f.kind().IsBreak() {
+		delta.completions = append(delta.completions, f.completion(f.phaseElapsed, true, now))
+		f.nextPhase(now)
+		return delta, true
+	}
+
+	worked := f.phaseElapsed
+	delta.completions = append(delta.completions, f.completion(worked, false, now))
+	f.nextPhase(now)
+	f.breakDur = time.Duration(float64(worked) * f.breakRatio).Round(time.Second)
+	return delta, true
+}
+
+// Lengthens or shortens the current break. Work phases have no planned end, so they are left alone.
+func (f *flowtime) adjust(d time.Duration) (advanceDelta, bool) {
+	if f.status != StatusRunning && f.status != StatusPaused {
+		return advanceDelta{}, false
+	}
+	if !f.kind().IsBreak() {
+		return advanceDelta{}, false
+	}
+
+	// Account for the time since the last tick; a break that ran out meanwhile completes instead of being adjusted.
+	delta := f.catchUp()
+	if len(delta.completions) > 0 || !f.kind().IsBreak() {
+		return delta, true
+	}
+
+	floor := min(f.phaseElapsed-f.breakDur, f.phaseAdjust)
+	f.phaseAdjust = max(f.phaseAdjust+d, floor)
+	return delta, true
+}
+
+func (f *flowtime) reset() bool {
+	if f.status == StatusInit && f.phaseIdx == 0 && f.phaseElapsed == 0 {
+		return false
+	}
+	f.phaseIdx = 0
+	f.phaseElapsed = 0
+	f.phaseAdjust = 0
+	f.breakDur = 0
+	f.phaseLastTick = time.Time{}
+	f.phaseLastWall = time.Time{}
+	f.phaseStarted = time.Time{}
+	f.status = StatusInit
+	return true
+}
+
+// Adds the time since the last tick to the current phase while running.
+func (f *flowtime) catchUp() advanceDelta {
+	delta := advanceDelta{}
+	if f.status == StatusRunning && !f.phaseLastTick.IsZero() && !f.phaseLastWall.IsZero() {
+		delta = f.advance(elapsedSinceLastTick(f.clock, f.phaseLastTick, f.phaseLastWall))
+	}
+	f.phaseLastTick = f.clock.Now()
+	f.phaseLastWall = f.clock.Wall()
+	return delta
+}
+
+// Work absorbs any amount of elapsed time.
+// A break that runs out completes and the remaining time carries over into the next work phase.
+func (f *flowtime) advance(elapsed time.Duration) advanceDelta {
+	completions := make([]phaseCompletion, 0, 1)
+	if f.kind().IsBreak() {
+		duration := f.breakDur + f.phaseAdjust
+		remaining := duration - f.phaseElapsed
+		if elapsed < remaining {
+			f.phaseElapsed += elapsed
+			return advanceDelta{completions: completions}
+		}
+		elapsed -= remaining
+		endedAt := f.phaseLastWall.Add(remaining)
+		completions = append(completions, f.completion(duration, false, endedAt))
+		f.nextPhase(endedAt)
+	}
+	f.phaseElapsed += elapsed
+	return advanceDelta{completions: completions}
+}
+
+func (f *flowtime) nextPhase(startedAt time.Time) {
+	f.phaseIdx++
+	f.phaseElapsed = 0
+	f.phaseAdjust = 0
+	f.phaseStarted = startedAt
+}
+
+func (f *flowtime) kind() PhaseKind {
+	if f.phaseIdx%2 == 0 {
+		return PhaseWork
+	}
+	return PhaseBreak
+}
+
+func (f *flowtime) completion(elapsed time.Duration, skipped bool, endedAt time.Time) phaseCompletion {
+	phase := f.phaseSnapshot()
+	phase.Remaining = phase.Duration - elapsed
+	if phase.Kind == PhaseWork {
+		phase.Duration = elapsed
+		phase.Remaining = 0
+	}
+	return phaseCompletion{
+		Phase:     phase,
+		Elapsed:   elapsed,
+		Skipped:   skipped,
+		StartedAt: f.phaseStarted,
+		EndedAt:   endedAt,
+	}
+}
+
+func (f *flowtime) snapshot() stateSnapshot {
+	return stateSnapshot{
+		Phase:  f.phaseSnapshot(),
+		Status: f.status,
+		Mode:   ModeFlowtime,
+	}
+}
+
+// Work phases have no planned duration, so their remaining time is the negative elapsed time.
+func (f *flowtime) phaseSnapshot() PhaseSnapshot {
+	var duration time.Duration
+	if f.kind().IsBreak() {
+		duration = f.breakDur + f.phaseAdjust
+	}
+	return PhaseSnapshot{
+		Idx:       f.phaseIdx,
+		HumanIdx:  f.phaseIdx/2 + 1,
+		Kind:      f.kind(),
+		Duration:  duration,
+		Remaining: duration - f.phaseElapsed,
+	}
+}
+
+func (f *flowtime) session() Session {
+	return Session{
+		Mode:         ModeFlowtime,
+		PhaseIdx:     f.phaseIdx,
+		PhaseElapsed: f.phaseElapsed,
+		PhaseAdjust:  f.phaseAdjust,
+		BreakDur:     f.breakDur,
+		BreakRatio:   f.breakRatio,
+		Status:       f.status,
+		StartedAt:    f.phaseStarted,
+		SavedAt:      f.phaseLastWall,
+	}
+}
+
+// Restores a saved session. See `state.restore`.
+// The break ratio comes with it, since the config may have moved on to another technique or ratio since.
+func (f *flowtime) restore(session Session) {
+	if session.PhaseIdx < 0 {
+		return
+	}
+	if session.BreakRatio > 0 {
+		f.breakRatio = session.BreakRatio
+	}
+	f.phaseIdx = session.PhaseIdx
+	f.phaseElapsed = session.PhaseElapsed
+	f.phaseAdjust = session.PhaseAdjust
+	f.breakDur = session.BreakDur
+	f.status = session.Status
+	f.phaseLastTick = f.clock.Now()
+	f.phaseLastWall = session.SavedAt
+	f.phaseStarted = session.StartedAt
+}
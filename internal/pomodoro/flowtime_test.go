@@ -0,0 +1,77 @@
+package pomodoro
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlowtimeBreakIsProportionalToWork(t *testing.T) {
+	f := newFlowtime(0.2)
+	if !f.start() {
+		t.Fatal("expected start to succeed")
+	}
+	f.advance(40 * time.Minute)
+	if snapshot := f.phaseSnapshot(); snapshot.Kind != PhaseWork || snapshot.Remaining != -40*time.Minute {
+		t.Fatalf("expected work counting up to 40 minutes, got kind=%s remaining=%s", snapshot.Kind, snapshot.Remaining)
+	}
+	f.status = StatusPaused
+
+	delta, skipped := f.skip()
+	if !skipped {
+		t.Fatal("expected ending work to succeed")
+	}
+	if len(delta.completions) != 1 {
+		t.Fatalf("expected 1 completion, got %d", len(delta.completions))
+	}
+	completion := delta.completions[0]
+	if completion.Skipped || completion.Elapsed != 40*time.Minute || completion.Phase.Duration != 40*time.Minute {
+		t.Fatalf("expected a natural 40 minute work completion, got skipped=%v elapsed=%s duration=%s", completion.Skipped, completion.Elapsed, completion.Phase.Duration)
+	}
+	if snapshot := f.phaseSnapshot(); snapshot.Kind != PhaseBreak || snapshot.Duration != 8*time.Minute {
+		t.Fatalf("expected an 8 minute break, got kind=%s duration=%s", snapshot.Kind, snapshot.Duration)
+	}
+}
+
+func TestFlowtimeBreakRollsIntoWork(t *testing.T) {
+	f := newFlowtime(0.2)
+	if !f.start() {
+		t.Fatal("expected start to succeed")
+	}
+	f.advance(25 * time.Minute)
+	f.status = StatusPaused
+	f.skip()
+
+	delta := f.advance(7 * time.Minute)
+	if len(delta.completions) != 1 || delta.completions[0].Phase.Kind != PhaseBreak {
+		t.Fatalf("expected the break to complete, got %+v", delta.completions)
+	}
+	snapshot := f.phaseSnapshot()
+	if snapshot.Kind != PhaseWork || snapshot.HumanIdx != 2 || snapshot.Remaining != -2*time.Minute {
+		t.Fatalf("expected work 2 two minutes in, got kind=%s human=%d remaining=%s", snapshot.Kind, snapshot.HumanIdx, snapshot.Remaining)
+	}
+}
+
+func TestFlowtimeAdjustCompletesBreakThatRanOut(t *testing.T) {
+	clock := newFakeClock()
+	f := newFlowtime(0.2)
+	f.clock = clock
+	if !f.start() {
+		t.Fatal("expected start to succeed")
+	}
+	f.advance(25 * time.Minute)
+	f.status = StatusPaused
+	f.skip()
+	f.status = StatusRunning
+	f.phaseLastTick = clock.Now()
+	f.phaseLastWall = clock.Wall()
+
+	// The 5 minute break ran out before the next tick.
+	clock.Advance(6 * time.Minute)
+	tr := f.apply(command{kind: commandAdjust, duration: 5 * time.Minute})
+	if len(tr.Completions) != 1 || tr.Completions[0].Phase.Kind != PhaseBreak {
+		t.Fatalf("expected the break completion to be reported, got %+v", tr.Completions)
+	}
+	if f.kind() != PhaseWork || f.phaseAdjust != 0 || f.phaseElapsed != time.Minute {
+		t.Fatalf("expected work one minute in and unadjusted, got kind=%s adjust=%s elapsed=%s", f.kind(), f.phaseAdjust, f.phaseElapsed)
+	}
+}
@@ -1,6 +1,9 @@
 package pomodoro
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -11,82 +14,333 @@ const (
 	interval = 250 * time.Millisecond
 )
 
+var (
+	// Returned when a command does not apply to the timer's current status, e.g. pausing a paused timer.
+	ErrInvalidState = errors.New("invalid state")
+	// Returned when the machine's loop is no longer running to accept commands.
+	ErrMachineStopped = errors.New("machine stopped")
+)
+
 // Pomodoro state machine that drives the timer, accepts commands, and broadcasts state change events.
 type Machine struct {
 	cmds        chan command
+	done        chan struct{}
 	mu          sync.Mutex
 	subscribers []chan Event
+	stopped     bool
 	processor   processor
+	clock       Clock
+	sessionPath string
 	logger      logs.Logger
 }
 
 // Create a new pomodoro state machine and receive a pointer to it.
 // Pass a logger to capture dropped events when debugging.
+// The plan is the ordered list of phases to run; see `AlternatingPlan`.
 //
 // NOTE: This was developed with the assumption that it is only called once in the application.
-func NewMachine(appLogger logs.Logger, workMinutes, breakMinutes, workPhases int) *Machine {
-	work := time.Duration(workMinutes) * time.Minute
-	breakTime := time.Duration(breakMinutes) * time.Minute
+func NewMachine(appLogger logs.Logger, plan []PhaseDetail, opts ...Option) *Machine {
+	o := options{mode: ModePomodoro, autoAdvance: true, clock: realClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	// A resumed session keeps the technique it was started with.
+	if o.session != nil {
+		o.mode = o.session.Mode
+	}
+
+	var p processor
+	switch o.mode {
+	case ModeFlowtime:
+		f := newFlowtime(o.flowtimeBreakRatio)
+		f.clock = o.clock
+		p = f
+	default:
+		s := newState(plan)
+		s.autoAdvance = o.autoAdvance
+		s.clock = o.clock
+		p = s
+	}
+	if o.session != nil {
+		p.restore(*o.session)
+	}
 	m := Machine{
 		cmds:        make(chan command, 10),
+		done:        make(chan struct{}),
 		subscribers: make([]chan Event, 0),
-		processor:   newState(work, breakTime, workPhases),
+		processor:   p,
+		clock:       o.clock,
+		sessionPath: o.sessionPath,
 		logger:      appLogger,
 	}
 
 	return &m
 }
 
+// Configures optional machine behavior in `NewMachine`.
+type Option func(*options)
+
+type options struct {
+	mode               Mode
+	autoAdvance        bool
+	flowtimeBreakRatio float64
+	clock              Clock
+	sessionPath        string
+	session            *Session
+}
+
+// Controls what happens when a phase runs out.
+// With auto advance (the default) the next phase starts right away.
+// Without it the phase goes into overtime and keeps counting until `Next` is called.
+func WithAutoAdvance(enabled bool) Option {
+	return func(o *options) {
+		o.autoAdvance = enabled
+	}
+}
+
+// Runs the Flowtime technique instead of the phase plan.
+// Work counts up until you end it with `Skip`, then a break of `breakRatio` times the work time counts down.
+func WithFlowtime(breakRatio float64) Option {
+	return func(o *options) {
+		o.mode = ModeFlowtime
+		o.flowtimeBreakRatio = breakRatio
+	}
+}
+
+// Replaces the real clock, for example with a fake one in tests.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// Saves the session to `path` on every state transition so it can be resumed later.
+// The file is removed once the session finishes or is reset.
+func WithSessionFile(path string) Option {
+	return func(o *options) {
+		o.sessionPath = path
+	}
+}
+
+// Resumes a session saved with `WithSessionFile`. See `LoadSession`.
+// Time that passed while the process was not running is caught up on the first tick.
+func WithSession(session Session) Option {
+	return func(o *options) {
+		o.session = &session
+	}
+}
+
 // Runs the internal loop that drives the timer in a goroutine.
-func (m *Machine) Run() {
-	go m.run()
+// Cancel `ctx` to stop the loop; every subscriber channel is closed and later commands return `ErrMachineStopped`.
+func (m *Machine) Run(ctx context.Context) {
+	go m.run(ctx)
 }
 
 // Create and add a unique channel to the machine's subscriptions list.
 // Use this channel to receive machine events.
-func (m *Machine) Subscribe() <-chan Event {
+// Call the returned function to unsubscribe, which closes the channel. Calling it more than once is fine.
+// The channel is also closed when the machine stops.
+func (m *Machine) Subscribe() (<-chan Event, func()) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	ch := make(chan Event, 10)
+	if m.stopped {
+		close(ch)
+		return ch, func() {}
+	}
 	m.subscribers = append(m.subscribers, ch)
-	return ch
+	return ch, func() { m.unsubscribe(ch) }
+}
+
+func (m *Machine) unsubscribe(ch chan Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, sub := range m.subscribers {
+		if sub == ch {
+			m.subscribers = append(m.subscribers[:i], m.subscribers[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+// Close every subscriber channel once the loop has exited.
+func (m *Machine) closeSubscribers() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.stopped = true
+	for _, ch := range m.subscribers {
+		close(ch)
+	}
+	m.subscribers = nil
 }
 
 // Starts the timer.
-// Only works if status is `StatusInit`, otherwise it is a no-op.
-func (m *Machine) Start() {
-	m.cmds <- commandStart
+// Only works if status is `StatusInit`, otherwise it returns `ErrInvalidState`.
+func (m *Machine) Start() error {
+	return m.StartContext(context.Background())
+}
+
+// Like `Start`, but gives up when ctx is done.
+func (m *Machine) StartContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandStart})
+}
+
+// Starts, pauses or resumes the timer, whichever fits the current status.
+// Once the timer finished it returns `ErrInvalidState`.
+func (m *Machine) Toggle() error {
+	return m.ToggleContext(context.Background())
+}
+
+// Like `Toggle`, but gives up when ctx is done.
+func (m *Machine) ToggleContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandToggle})
 }
 
 // Pauses the timer.
-// Only works if status is `StatusRunning`, otherwise it is a no-op.
-func (m *Machine) Pause() {
-	m.cmds <- commandPause
+// Only works if status is `StatusRunning`, otherwise it returns `ErrInvalidState`.
+func (m *Machine) Pause() error {
+	return m.PauseContext(context.Background())
+}
+
+// Like `Pause`, but gives up when ctx is done.
+func (m *Machine) PauseContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandPause})
 }
 
 // Resumes the timer.
-// Only works is status is `StatusPaused`, otherwise it is a no-op.
-func (m *Machine) Resume() {
-	m.cmds <- commandResume
+// Only works if status is `StatusPaused`, otherwise it returns `ErrInvalidState`.
+func (m *Machine) Resume() error {
+	return m.ResumeContext(context.Background())
+}
+
+// Like `Resume`, but gives up when ctx is done.
+func (m *Machine) ResumeContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandResume})
+}
+
+// Skips the current break (short or long) and advances to the next work phase.
+// Only works during breaks while running or paused, otherwise it returns `ErrInvalidState`.
+func (m *Machine) SkipBreak() error {
+	return m.SkipBreakContext(context.Background())
+}
+
+// Like `SkipBreak`, but gives up when ctx is done.
+func (m *Machine) SkipBreakContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandSkipBreak})
+}
+
+// Ends the current phase early, whether work or break, and advances to the next one.
+// The phase is reported as skipped along with the time actually spent in it.
+// Only works while running or paused, otherwise it returns `ErrInvalidState`.
+func (m *Machine) Skip() error {
+	return m.SkipContext(context.Background())
+}
+
+// Like `Skip`, but gives up when ctx is done.
+func (m *Machine) SkipContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandSkip})
 }
 
-// Skips the current break and advances to the next work phase.
-// Only works during breaks while running or paused, otherwise it is a no-op.
-func (m *Machine) SkipBreak() {
-	m.cmds <- commandSkipBreak
+// Ends a phase that is in overtime and advances to the next one.
+// Only works while the current phase is in overtime, otherwise it returns `ErrInvalidState`.
+func (m *Machine) Next() error {
+	return m.NextContext(context.Background())
+}
+
+// Like `Next`, but gives up when ctx is done.
+func (m *Machine) NextContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandNext})
+}
+
+// Lengthens the current phase by `d`.
+// Only works while running or paused, otherwise it returns `ErrInvalidState`.
+func (m *Machine) Extend(d time.Duration) error {
+	return m.ExtendContext(context.Background(), d)
+}
+
+// Like `Extend`, but gives up when ctx is done.
+func (m *Machine) ExtendContext(ctx context.Context, d time.Duration) error {
+	return m.send(ctx, command{kind: commandAdjust, duration: d})
+}
+
+// Shortens the current phase by `d`, but never below the time already spent in it.
+// Only works while running or paused, otherwise it returns `ErrInvalidState`.
+func (m *Machine) Shorten(d time.Duration) error {
+	return m.ShortenContext(context.Background(), d)
+}
+
+// Like `Shorten`, but gives up when ctx is done.
+func (m *Machine) ShortenContext(ctx context.Context, d time.Duration) error {
+	return m.send(ctx, command{kind: commandAdjust, duration: -d})
+}
+
+// Stops the timer and discards progress, returning to the first phase in `StatusInit`.
+// Works in any status, including after the timer finished.
+func (m *Machine) Reset() error {
+	return m.ResetContext(context.Background())
+}
+
+// Like `Reset`, but gives up when ctx is done.
+func (m *Machine) ResetContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandReset})
+}
+
+// Begins a new cycle from the first phase and starts the timer right away.
+// Pass a new plan, for example from a freshly loaded config, or nil to repeat the current one.
+// Works in any status, including after the timer finished.
+func (m *Machine) Restart(plan []PhaseDetail) error {
+	return m.RestartContext(context.Background(), plan)
+}
+
+// Like `Restart`, but gives up when ctx is done.
+func (m *Machine) RestartContext(ctx context.Context, plan []PhaseDetail) error {
+	return m.send(ctx, command{kind: commandRestart, plan: plan})
 }
 
 // Requests a snapshot of the current machine state.
 // The state is broadcasted with the event `EventStateChanged`.
-func (m *Machine) GetState() {
-	m.cmds <- commandGetState
+func (m *Machine) GetState() error {
+	return m.GetStateContext(context.Background())
+}
+
+// Like `GetState`, but gives up when ctx is done.
+func (m *Machine) GetStateContext(ctx context.Context) error {
+	return m.send(ctx, command{kind: commandGetState})
+}
+
+// Hands a command to the loop and waits until it has been applied.
+// Gives up when the context is done or the loop has exited.
+func (m *Machine) send(ctx context.Context, cmd command) error {
+	cmd.reply = make(chan error, 1)
+	select {
+	case m.cmds <- cmd:
+	case <-m.done:
+		return ErrMachineStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-cmd.reply:
+		return err
+	case <-m.done:
+		return ErrMachineStopped
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // Internal loop to run the state machine.
 // It forwards commands to the state processor and responds with events on state transitions.
-func (m *Machine) run() {
-	var ticker *time.Ticker
+func (m *Machine) run(ctx context.Context) {
+	defer m.closeSubscribers()
+	defer close(m.done)
+
+	var ticker Ticker
 	var tickCh <-chan time.Time
 	defer func() {
 		if ticker != nil {
@@ -94,54 +348,122 @@ func (m *Machine) run() {
 		}
 	}()
 
+	// A resumed session may already be running.
+	if m.processor.snapshot().Status == StatusRunning {
+		ticker = m.clock.NewTicker(interval)
+		tickCh = ticker.C()
+	}
+
 	for {
 		select {
+		case <-ctx.Done():
+			return
+
 		case cmd := <-m.cmds:
-			transition := m.processor.apply(cmd)
-			events := eventsFromTransition(transition)
-			for _, event := range events {
-				m.broadcast(event)
+			if cmd.kind == commandToggle {
+				status := m.processor.snapshot().Status
+				kind, ok := toggleTarget(status)
+				if !ok {
+					cmd.reply <- fmt.Errorf("%w: cannot %s while %s", ErrInvalidState, cmd.kind, status)
+					continue
+				}
+				cmd.kind = kind
 			}
+			transition := m.processor.apply(cmd)
 
-			// Create a new ticker on "start" and "resume".
-			// Stop and nil the ticker on "pause".
-			switch cmd {
+			// Create a new ticker on "start", "resume" and "restart".
+			// Stop and nil the ticker on "pause" and "reset".
+			switch cmd.kind {
 			case commandStart:
 				if transition.To.Status == StatusRunning && ticker == nil {
-					ticker = time.NewTicker(interval)
-					tickCh = ticker.C
+					ticker = m.clock.NewTicker(interval)
+					tickCh = ticker.C()
 				}
-			case commandResume:
+			case commandResume, commandRestart:
 				if transition.To.Status == StatusRunning && ticker == nil {
-					ticker = time.NewTicker(interval)
-					tickCh = ticker.C
+					ticker = m.clock.NewTicker(interval)
+					tickCh = ticker.C()
 				}
-			case commandPause:
+			case commandPause, commandReset:
 				if ticker != nil {
 					ticker.Stop()
 					ticker = nil
 					tickCh = nil
 				}
-			case commandSkipBreak:
-				// No ticker changes.
+			case commandSkipBreak, commandSkip, commandAdjust, commandNext:
+				// Skipping or catching up on the last phase can finish the timer.
+				if transition.Finished && ticker != nil {
+					ticker.Stop()
+					ticker = nil
+					tickCh = nil
+				}
 			case commandGetState:
 				// No ticker changes.
 			}
 
+			if transition.EmitState || len(transition.Completions) > 0 {
+				m.persist(transition.To)
+			}
+
+			// Broadcast after the ticker is in place so subscribers observe a consistent machine.
+			events := eventsFromTransition(transition)
+			for _, event := range events {
+				m.broadcast(event)
+			}
+			cmd.reply <- transition.Err
+
 		// Advance the timer on every tick.
 		case <-tickCh:
 			transition := m.processor.tick()
+			// Elapsed time within a phase is recovered from the saved timestamp,
+			// so only ticks that move to another phase need to be saved.
+			if len(transition.Completions) > 0 || transition.Finished || transition.Overtime {
+				m.persist(transition.To)
+			}
 			events := eventsFromTransition(transition)
 			for _, event := range events {
 				m.broadcast(event)
 			}
-			if transition.Finished {
-				return
+			// Keep the loop alive after the timer finishes so it can be reset or restarted.
+			if transition.Finished && ticker != nil {
+				ticker.Stop()
+				ticker = nil
+				tickCh = nil
 			}
 		}
 	}
 }
 
+// The command a toggle stands for in the given status. A finished timer has nothing to toggle.
+func toggleTarget(status TimerStatus) (commandKind, bool) {
+	switch status {
+	case StatusInit:
+		return commandStart, true
+	case StatusRunning:
+		return commandPause, true
+	case StatusPaused:
+		return commandResume, true
+	default:
+		return commandToggle, false
+	}
+}
+
+// Save the session file, or remove it when there is nothing left to resume.
+func (m *Machine) persist(snapshot stateSnapshot) {
+	if m.sessionPath == "" {
+		return
+	}
+	var err error
+	if snapshot.Status == StatusRunning || snapshot.Status == StatusPaused {
+		err = saveSession(m.sessionPath, m.processor.session())
+	} else {
+		err = removeSession(m.sessionPath)
+	}
+	if err != nil && m.logger != nil {
+		m.logger.Printf("session persist failed: %v", err)
+	}
+}
+
 func (m *Machine) broadcast(event Event) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -164,13 +486,29 @@ func (m *Machine) broadcast(event Event) {
 type Event interface{}
 
 type EventStateChanged struct {
-	Phase      PhaseSnapshot
-	Status     TimerStatus
-	WorkPhases int
+	Phase      PhaseSnapshot `json:"phase"`
+	Status     TimerStatus   `json:"status"`
+	Mode       Mode          `json:"mode"`
+	WorkPhases int           `json:"work_phases"`
+	Plan       []PhaseDetail `json:"plan,omitempty"`
 }
 
+// Sent when a phase ends, either naturally or because it was skipped.
+// `Elapsed` is the time actually spent in the phase, including any `Overtime`.
+// `Final` marks the last phase of the plan; an `EventTimerFinished` follows it.
 type EventPhaseFinished struct {
-	Phase PhaseSnapshot
+	Phase     PhaseSnapshot `json:"phase"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Overtime  time.Duration `json:"overtime,omitempty"`
+	Skipped   bool          `json:"skipped,omitempty"`
+	Final     bool          `json:"final,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	EndedAt   time.Time     `json:"ended_at"`
+}
+
+// Sent once when a phase runs out without auto advance and starts counting overtime.
+type EventPhaseOvertime struct {
+	Phase PhaseSnapshot `json:"phase"`
 }
 
 type EventTimerFinished struct{}
@@ -182,7 +520,18 @@ func eventsFromTransition(transition transition) []Event {
 	events := make([]Event, 0, len(transition.Completions)+2)
 	for _, completion := range transition.Completions {
 		events = append(events, EventPhaseFinished{
-			Phase: completion.Phase,
+			Phase:     completion.Phase,
+			Elapsed:   completion.Elapsed,
+			Overtime:  completion.Overtime,
+			Skipped:   completion.Skipped,
+			Final:     completion.Final,
+			StartedAt: completion.StartedAt,
+			EndedAt:   completion.EndedAt,
+		})
+	}
+	if transition.Overtime {
+		events = append(events, EventPhaseOvertime{
+			Phase: transition.To.Phase,
 		})
 	}
 	if transition.Finished {
@@ -199,6 +548,9 @@ func stateChangedEvent(snapshot stateSnapshot) EventStateChanged {
 	return EventStateChanged{
 		Phase:      snapshot.Phase,
 		Status:     snapshot.Status,
+		Mode:       snapshot.Mode,
 		WorkPhases: snapshot.WorkPhases,
+		// Copy the plan so subscribers cannot mutate the machine's state.
+		Plan: append([]PhaseDetail(nil), snapshot.Plan...),
 	}
 }
@@ -0,0 +1,316 @@
+package pomodoro
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestMachine(t *testing.T, plan []PhaseDetail, opts ...Option) (*Machine, *fakeClock, <-chan Event) {
+	t.Helper()
+	clock := newFakeClock()
+	m := NewMachine(nil, plan, append(opts, WithClock(clock))...)
+	events, _ := m.Subscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	m.Run(ctx)
+	return m, clock, events
+}
+
+// Collects events until one matches `done`, failing the test if none arrives in time.
+func waitForEvent(t *testing.T, events <-chan Event, done func(Event) bool) []Event {
+	t.Helper()
+	var seen []Event
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event := <-events:
+			seen = append(seen, event)
+			if done(event) {
+				return seen
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for event, saw %+v", seen)
+			return nil
+		}
+	}
+}
+
+func isStateChanged(event Event) bool {
+	_, ok := event.(EventStateChanged)
+	return ok
+}
+
+func phaseFinishedEvents(events []Event) []EventPhaseFinished {
+	finished := make([]EventPhaseFinished, 0)
+	for _, event := range events {
+		if event, ok := event.(EventPhaseFinished); ok {
+			finished = append(finished, event)
+		}
+	}
+	return finished
+}
+
+func TestMachineCatchesUpMultiplePhases(t *testing.T) {
+	m, clock, events := newTestMachine(t, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	m.Start()
+	waitForEvent(t, events, isStateChanged)
+
+	clock.Advance(65 * time.Minute)
+	seen := waitForEvent(t, events, isStateChanged)
+
+	finished := phaseFinishedEvents(seen)
+	if len(finished) != 4 {
+		t.Fatalf("expected 4 phases to finish, got %d", len(finished))
+	}
+	start := newFakeClock().Wall()
+	if !finished[1].StartedAt.Equal(start.Add(25*time.Minute)) || !finished[1].EndedAt.Equal(start.Add(30*time.Minute)) {
+		t.Fatalf("expected the first break to span minutes 25 to 30, got %s to %s", finished[1].StartedAt, finished[1].EndedAt)
+	}
+	state := seen[len(seen)-1].(EventStateChanged)
+	if state.Phase.Idx != 4 || state.Phase.Remaining != 20*time.Minute {
+		t.Fatalf("expected phase 4 with 20 minutes left, got idx=%d remaining=%s", state.Phase.Idx, state.Phase.Remaining)
+	}
+}
+
+func TestMachineUsesWallClockAfterSleep(t *testing.T) {
+	m, clock, events := newTestMachine(t, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	m.Start()
+	waitForEvent(t, events, isStateChanged)
+
+	// The monotonic clock stops while suspended, so only the wall clock notices the 40 minutes.
+	clock.Sleep(40 * time.Minute)
+	clock.Advance(interval)
+	seen := waitForEvent(t, events, isStateChanged)
+
+	if finished := phaseFinishedEvents(seen); len(finished) != 2 {
+		t.Fatalf("expected work and break to finish during sleep, got %d completions", len(finished))
+	}
+	state := seen[len(seen)-1].(EventStateChanged)
+	if state.Phase.Idx != 2 || state.Phase.Remaining != 15*time.Minute-interval {
+		t.Fatalf("expected phase 2 with %s left, got idx=%d remaining=%s", 15*time.Minute-interval, state.Phase.Idx, state.Phase.Remaining)
+	}
+}
+
+func TestMachineIgnoresSmallWallClockDrift(t *testing.T) {
+	m, clock, events := newTestMachine(t, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	m.Start()
+	waitForEvent(t, events, isStateChanged)
+
+	// A small wall clock adjustment, such as an NTP correction, is below the sleep threshold.
+	clock.Sleep(2 * time.Second)
+	clock.Advance(time.Minute)
+	seen := waitForEvent(t, events, isStateChanged)
+
+	state := seen[len(seen)-1].(EventStateChanged)
+	if state.Phase.Remaining != 24*time.Minute {
+		t.Fatalf("expected monotonic elapsed time to be used, got remaining=%s", state.Phase.Remaining)
+	}
+}
+
+func TestMachineFinishesAndRestarts(t *testing.T) {
+	m, clock, events := newTestMachine(t, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 2, 0))
+	m.Start()
+	waitForEvent(t, events, isStateChanged)
+
+	clock.Advance(time.Hour)
+	waitForEvent(t, events, func(event Event) bool {
+		_, ok := event.(EventTimerFinished)
+		return ok
+	})
+
+	m.Restart(nil)
+	seen := waitForEvent(t, events, func(event Event) bool {
+		state, ok := event.(EventStateChanged)
+		return ok && state.Status == StatusRunning
+	})
+	state := seen[len(seen)-1].(EventStateChanged)
+	if state.Phase.Idx != 0 || state.Phase.Remaining != 25*time.Minute {
+		t.Fatalf("expected a fresh cycle, got idx=%d remaining=%s", state.Phase.Idx, state.Phase.Remaining)
+	}
+
+	clock.Advance(10 * time.Minute)
+	seen = waitForEvent(t, events, isStateChanged)
+	state = seen[len(seen)-1].(EventStateChanged)
+	if state.Phase.Remaining != 15*time.Minute {
+		t.Fatalf("expected the restarted timer to tick, got remaining=%s", state.Phase.Remaining)
+	}
+}
+
+func TestMachinePersistsAndResumesSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	plan := AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0)
+	m, clock, events := newTestMachine(t, plan, WithSessionFile(path))
+	m.Start()
+	waitForEvent(t, events, isStateChanged)
+	clock.Advance(10 * time.Minute)
+	waitForEvent(t, events, isStateChanged)
+	m.Pause()
+	waitForEvent(t, events, isStateChanged)
+	m.Resume()
+	waitForEvent(t, events, isStateChanged)
+
+	session, err := LoadSession(path)
+	if err != nil {
+		t.Fatalf("expected a saved session, got %v", err)
+	}
+	if !session.Resumable() || session.PhaseElapsed != 10*time.Minute {
+		t.Fatalf("expected a running session 10 minutes in, got status=%v elapsed=%s", session.Status, session.PhaseElapsed)
+	}
+	// Idle the first machine so it does not tick along with the resumed one on the shared clock.
+	if err := m.Reset(); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+
+	// The process was gone for 17 minutes, which carries the session into the break.
+	resumed := NewMachine(nil, nil, WithClock(clock), WithSession(session))
+	resumedEvents, _ := resumed.Subscribe()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resumed.Run(ctx)
+	resumed.GetState()
+	waitForEvent(t, resumedEvents, isStateChanged)
+	clock.Sleep(17 * time.Minute)
+	clock.Advance(interval)
+	seen := waitForEvent(t, resumedEvents, isStateChanged)
+
+	if finished := phaseFinishedEvents(seen); len(finished) != 1 || finished[0].Phase.Kind != PhaseWork {
+		t.Fatalf("expected the work phase to finish while away, got %+v", finished)
+	}
+	state := seen[len(seen)-1].(EventStateChanged)
+	if state.Phase.Idx != 1 || state.Phase.Remaining != 3*time.Minute-interval {
+		t.Fatalf("expected the break with %s left, got idx=%d remaining=%s", 3*time.Minute-interval, state.Phase.Idx, state.Phase.Remaining)
+	}
+}
+
+func TestMachineResumesFlowtimeWithItsBreakRatio(t *testing.T) {
+	// The config went back to pomodoro, so the machine gets a plan and no `WithFlowtime`.
+	plan := AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0)
+	session := Session{Mode: ModeFlowtime, PhaseElapsed: 40 * time.Minute, BreakRatio: 0.2, Status: StatusPaused}
+	m, _, events := newTestMachine(t, plan, WithSession(session))
+
+	if err := m.Skip(); err != nil {
+		t.Fatalf("skip failed: %v", err)
+	}
+	seen := waitForEvent(t, events, isStateChanged)
+	state := seen[len(seen)-1].(EventStateChanged)
+	if state.Phase.Kind != PhaseBreak || state.Phase.Duration != 8*time.Minute {
+		t.Fatalf("expected an 8 minute break, got kind=%s duration=%s", state.Phase.Kind, state.Phase.Duration)
+	}
+}
+
+func TestMachineToggleFollowsStatus(t *testing.T) {
+	m, _, events := newTestMachine(t, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+
+	for _, want := range []TimerStatus{StatusRunning, StatusPaused, StatusRunning} {
+		m.Toggle()
+		seen := waitForEvent(t, events, isStateChanged)
+		if got := seen[len(seen)-1].(EventStateChanged).Status; got != want {
+			t.Fatalf("expected %s after toggle, got %s", want, got)
+		}
+	}
+}
+
+func TestMachineReportsRejectedCommands(t *testing.T) {
+	m, _, _ := newTestMachine(t, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+
+	if err := m.Pause(); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected pausing an idle timer to fail with ErrInvalidState, got %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("expected start to succeed, got %v", err)
+	}
+	if err := m.SkipBreak(); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected skipping a break during work to fail with ErrInvalidState, got %v", err)
+	}
+	if err := m.Reset(); err != nil {
+		t.Fatalf("expected reset to succeed, got %v", err)
+	}
+	if err := m.Reset(); err != nil {
+		t.Fatalf("expected resetting twice to succeed, got %v", err)
+	}
+}
+
+func TestMachineCommandsGiveUpWithoutLoop(t *testing.T) {
+	m := NewMachine(nil, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.StartContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the context deadline while nothing runs the loop, got %v", err)
+	}
+
+	close(m.done)
+	if err := m.Start(); !errors.Is(err, ErrMachineStopped) {
+		t.Fatalf("expected ErrMachineStopped once the loop exited, got %v", err)
+	}
+}
+
+func TestMachineUnsubscribeClosesChannel(t *testing.T) {
+	m, _, events := newTestMachine(t, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	other, unsubscribe := m.Subscribe()
+
+	unsubscribe()
+	unsubscribe()
+	if _, ok := <-other; ok {
+		t.Fatalf("expected the unsubscribed channel to be closed")
+	}
+
+	// The remaining subscriber keeps receiving events.
+	if err := m.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	waitForEvent(t, events, isStateChanged)
+}
+
+func TestMachineStopsWithoutLeakingGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	m := NewMachine(nil, AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0), WithClock(newFakeClock()))
+	ctx, cancel := context.WithCancel(context.Background())
+	var consumers sync.WaitGroup
+	for range 3 {
+		events, _ := m.Subscribe()
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for range events {
+			}
+		}()
+	}
+	m.Run(ctx)
+	if err := m.Start(); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	cancel()
+	// Consumers ranging over their channels only return once the machine closes them.
+	consumers.Wait()
+	if err := m.Pause(); !errors.Is(err, ErrMachineStopped) {
+		t.Fatalf("expected ErrMachineStopped after shutdown, got %v", err)
+	}
+	if events, _ := m.Subscribe(); !isClosed(events) {
+		t.Fatalf("expected subscribing to a stopped machine to return a closed channel")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d goroutines after shutdown, got %d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func isClosed(events <-chan Event) bool {
+	select {
+	case _, ok := <-events:
+		return !ok
+	default:
+		return false
+	}
+}
@@ -0,0 +1,47 @@
+package pomodoro
+
+import "time"
+
+// Build a plan of `workPhases` work phases separated by breaks.
+// When `longBreakEvery` is positive, every Nth break lasts `longBreakDur` instead of `breakDur`.
+func AlternatingPlan(workDur, breakDur, longBreakDur time.Duration, workPhases, longBreakEvery int) []PhaseDetail {
+	if workPhases <= 0 {
+		return nil
+	}
+	plan := make([]PhaseDetail, 0, workPhases*2-1)
+	for i := 1; i <= workPhases; i++ {
+		plan = append(plan, PhaseDetail{Kind: PhaseWork, Duration: workDur})
+		if i == workPhases {
+			break
+		}
+		if longBreakEvery > 0 && i%longBreakEvery == 0 {
+			plan = append(plan, PhaseDetail{Kind: PhaseLongBreak, Duration: longBreakDur})
+		} else {
+			plan = append(plan, PhaseDetail{Kind: PhaseBreak, Duration: breakDur})
+		}
+	}
+	return plan
+}
+
+// Counts the work phases in a plan.
+func planWorkPhases(plan []PhaseDetail) int {
+	count := 0
+	for _, phase := range plan {
+		if phase.Kind == PhaseWork {
+			count++
+		}
+	}
+	return count
+}
+
+// Human friendly index of a phase.
+// Work phases are numbered in order. Breaks share the number of the work phase before them.
+func planHumanIdx(plan []PhaseDetail, idx int) int {
+	count := 0
+	for i := 0; i <= idx && i < len(plan); i++ {
+		if plan[i].Kind == PhaseWork {
+			count++
+		}
+	}
+	return max(count, 1)
+}
@@ -1,66 +1,154 @@
 package pomodoro
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // Interface meant to be implemented to apply state transitions.
-// See `internal/pomodoro/state.go`.
+// See `internal/pomodoro/state.go` and `internal/pomodoro/flowtime.go`.
 type processor interface {
 	apply(cmd command) transition
 	tick() transition
+	snapshot() stateSnapshot
+	session() Session
+	restore(session Session)
+}
+
+// A command sent to the processor.
+// Only some kinds carry a payload; see the field comments.
+type command struct {
+	kind commandKind
+	// Replacement phase plan for `commandRestart`. Nil keeps the current plan.
+	plan []PhaseDetail
+	// Amount to lengthen (positive) or shorten (negative) the phase for `commandAdjust`.
+	duration time.Duration
+	// Receives the result once the command was applied and its events broadcast. Optional.
+	reply chan error
 }
 
-type command int
+type commandKind int
 
 const (
-	commandStart command = iota
+	commandStart commandKind = iota
 	commandPause
 	commandResume
 	commandSkipBreak
 	commandGetState
+	commandReset
+	commandRestart
+	commandSkip
+	commandAdjust
+	commandNext
+	// Resolved by the machine into start, pause or resume before reaching the processor.
+	commandToggle
 )
 
+var commandNames = map[commandKind]string{
+	commandStart:     "start",
+	commandPause:     "pause",
+	commandResume:    "resume",
+	commandSkipBreak: "skip break",
+	commandGetState:  "get state",
+	commandReset:     "reset",
+	commandRestart:   "restart",
+	commandSkip:      "skip",
+	commandAdjust:    "adjust",
+	commandNext:      "next",
+	commandToggle:    "toggle",
+}
+
+func (k commandKind) String() string {
+	if name, ok := commandNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("commandKind(%d)", int(k))
+}
+
+// Result of a command for the processors.
+// A command that changed nothing was not valid in the status it arrived in.
+// Reset and get state always succeed, since resetting a timer that is already reset is harmless.
+func commandError(kind commandKind, status TimerStatus, applied bool) error {
+	if applied || kind == commandReset || kind == commandGetState {
+		return nil
+	}
+	return fmt.Errorf("%w: cannot %s while %s", ErrInvalidState, kind, status)
+}
+
 type transition struct {
 	From        stateSnapshot
 	To          stateSnapshot
 	Completions []phaseCompletion
 	Finished    bool
-	EmitState   bool
+	// The current phase ran out and went into overtime during this transition.
+	Overtime  bool
+	EmitState bool
+	// Set when the command was rejected; see `commandError`.
+	Err error
 }
 
 type stateSnapshot struct {
 	Phase      PhaseSnapshot
 	Status     TimerStatus
+	Mode       Mode
 	WorkPhases int
+	Plan       []PhaseDetail
 }
 
 type phaseCompletion struct {
 	Phase PhaseSnapshot
+	// Time actually spent in the phase. Shorter than the planned duration when skipped.
+	Elapsed time.Duration
+	// Time spent past the end of the phase while waiting for "next".
+	Overtime time.Duration
+	Skipped  bool
+	// The last phase of the plan; the timer finishes with it.
+	Final     bool
+	StartedAt time.Time
+	EndedAt   time.Time
 }
 
 type PhaseSnapshot struct {
-	Idx       int
-	HumanIdx  int
-	Kind      PhaseKind
-	Duration  time.Duration
-	Remaining time.Duration
+	Idx      int           `json:"idx"`
+	HumanIdx int           `json:"human_idx"`
+	Kind     PhaseKind     `json:"kind"`
+	Duration time.Duration `json:"duration"`
+	// Negative while the phase is in overtime.
+	Remaining time.Duration `json:"remaining"`
+	Label     string        `json:"label,omitempty"`
+	Overtime  bool          `json:"overtime,omitempty"`
 }
 
+// A single entry of the phase plan. The label is optional.
 type PhaseDetail struct {
-	Kind     PhaseKind
-	Duration time.Duration
+	Kind     PhaseKind     `json:"kind"`
+	Duration time.Duration `json:"duration"`
+	Label    string        `json:"label,omitempty"`
 }
 
 type PhaseKind string
 
 const (
-	PhaseWork  PhaseKind = "Work"
-	PhaseBreak PhaseKind = "Break"
+	PhaseWork      PhaseKind = "Work"
+	PhaseBreak     PhaseKind = "Break"
+	PhaseLongBreak PhaseKind = "Long break"
 )
 
-func phaseHumanIdx(phase int) int {
-	return phase/2 + 1
+// Reports whether the phase kind is any kind of break.
+func (k PhaseKind) IsBreak() bool {
+	return k == PhaseBreak || k == PhaseLongBreak
 }
 
+// Timing technique driving the machine.
+type Mode string
+
+const (
+	// Fixed phases following a plan. See `internal/pomodoro/state.go`.
+	ModePomodoro Mode = "pomodoro"
+	// Open-ended work followed by proportional breaks. See `internal/pomodoro/flowtime.go`.
+	ModeFlowtime Mode = "flowtime"
+)
+
 type TimerStatus int
 
 const (
@@ -69,3 +157,36 @@ const (
 	StatusPaused
 	StatusFinished
 )
+
+var statusNames = map[TimerStatus]string{
+	StatusInit:     "init",
+	StatusRunning:  "running",
+	StatusPaused:   "paused",
+	StatusFinished: "finished",
+}
+
+func (s TimerStatus) String() string {
+	if name, ok := statusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("TimerStatus(%d)", int(s))
+}
+
+// Statuses are encoded by name so the session file and the control protocol stay readable.
+func (s TimerStatus) MarshalText() ([]byte, error) {
+	name, ok := statusNames[s]
+	if !ok {
+		return nil, fmt.Errorf("unknown timer status %d", int(s))
+	}
+	return []byte(name), nil
+}
+
+func (s *TimerStatus) UnmarshalText(text []byte) error {
+	for status, name := range statusNames {
+		if name == string(text) {
+			*s = status
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown timer status %q", text)
+}
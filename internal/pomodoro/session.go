@@ -0,0 +1,77 @@
+package pomodoro
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/xdg"
+)
+
+// Persisted timer state used to resume a session after the process exits.
+// `PhaseElapsed` is accurate as of the wall clock time `SavedAt`; a running session catches up from there on resume.
+type Session struct {
+	Mode         Mode          `json:"mode"`
+	Plan         []PhaseDetail `json:"plan,omitempty"`
+	PhaseIdx     int           `json:"phase_idx"`
+	PhaseElapsed time.Duration `json:"phase_elapsed"`
+	PhaseAdjust  time.Duration `json:"phase_adjust,omitempty"`
+	BreakDur     time.Duration `json:"break_duration,omitempty"`
+	BreakRatio   float64       `json:"break_ratio,omitempty"`
+	Overtime     bool          `json:"overtime,omitempty"`
+	Status       TimerStatus   `json:"status"`
+	StartedAt    time.Time     `json:"started_at"`
+	SavedAt      time.Time     `json:"saved_at"`
+}
+
+// Reports whether the session has progress worth resuming.
+func (s Session) Resumable() bool {
+	return s.Status == StatusRunning || s.Status == StatusPaused
+}
+
+// Location of the session file under the user's state directory.
+func SessionPath() (string, error) {
+	dir, err := xdg.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cadence", "session.json"), nil
+}
+
+// Read a saved session. A missing file returns `os.ErrNotExist`.
+func LoadSession(path string) (Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Session{}, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// Write the session atomically so a crash never leaves a truncated file behind.
+func saveSession(path string, session Session) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func removeSession(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -5,30 +5,37 @@ import (
 )
 
 type state struct {
-	workDur       time.Duration
-	breakDur      time.Duration
+	plan          []PhaseDetail
 	workPhases    int
 	phaseCnt      int
 	phaseIdx      int
 	phaseElapsed  time.Duration
+	phaseAdjust   time.Duration
+	autoAdvance   bool
+	overtime      bool
 	phaseLastTick time.Time
 	phaseLastWall time.Time
+	phaseStarted  time.Time
 	status        TimerStatus
+	clock         Clock
 }
 
 type advanceDelta struct {
 	completions []phaseCompletion
 	finished    bool
+	overtime    bool
 }
 
-func newState(workDur time.Duration, breakDur time.Duration, workPhases int) *state {
+// Create the timer state for an ordered phase plan.
+func newState(plan []PhaseDetail) *state {
 	return &state{
-		workDur:    workDur,
-		breakDur:   breakDur,
-		workPhases: workPhases,
-		phaseCnt:   (workPhases * 2) - 1,
-		phaseIdx:   0,
-		status:     StatusInit,
+		plan:        plan,
+		workPhases:  planWorkPhases(plan),
+		phaseCnt:    len(plan),
+		phaseIdx:    0,
+		autoAdvance: true,
+		status:      StatusInit,
+		clock:       realClock{},
 	}
 }
 
@@ -38,7 +45,7 @@ func (s *state) apply(cmd command) transition {
 	delta := advanceDelta{}
 	emitState := false
 
-	switch cmd {
+	switch cmd.kind {
 	case commandStart:
 		if s.start() {
 			emitState = true
@@ -59,8 +66,34 @@ func (s *state) apply(cmd command) transition {
 		if skipped {
 			emitState = true
 		}
+	case commandSkip:
+		var skipped bool
+		delta, skipped = s.skip()
+		if skipped {
+			emitState = true
+		}
+	case commandNext:
+		var advanced bool
+		delta, advanced = s.next()
+		if advanced {
+			emitState = true
+		}
+	case commandAdjust:
+		var adjusted bool
+		delta, adjusted = s.adjust(cmd.duration)
+		if adjusted {
+			emitState = true
+		}
 	case commandGetState:
 		emitState = true
+	case commandReset:
+		if s.reset() {
+			emitState = true
+		}
+	case commandRestart:
+		if s.restart(cmd.plan) {
+			emitState = true
+		}
 	}
 
 	if emitState || delta.finished || len(delta.completions) > 0 {
@@ -72,7 +105,9 @@ func (s *state) apply(cmd command) transition {
 		To:          after,
 		Completions: delta.completions,
 		Finished:    delta.finished,
+		Overtime:    delta.overtime,
 		EmitState:   emitState,
+		Err:         commandError(cmd.kind, before.Status, emitState),
 	}
 }
 
@@ -86,10 +121,10 @@ func (s *state) tick() transition {
 		}
 	}
 
-	elapsed := elapsedSinceLastTick(s.phaseLastTick, s.phaseLastWall)
+	elapsed := elapsedSinceLastTick(s.clock, s.phaseLastTick, s.phaseLastWall)
 	delta := s.advance(elapsed)
-	s.phaseLastTick = time.Now()
-	s.phaseLastWall = nowWallClock()
+	s.phaseLastTick = s.clock.Now()
+	s.phaseLastWall = s.clock.Wall()
 
 	after := s.snapshot()
 	return transition{
@@ -97,6 +132,7 @@ func (s *state) tick() transition {
 		To:          after,
 		Completions: delta.completions,
 		Finished:    delta.finished,
+		Overtime:    delta.overtime,
 		EmitState:   true,
 	}
 }
@@ -104,8 +140,9 @@ func (s *state) tick() transition {
 func (s *state) start() bool {
 	if s.status == StatusInit {
 		s.phaseElapsed = time.Second * 0
-		s.phaseLastTick = time.Now()
-		s.phaseLastWall = nowWallClock()
+		s.phaseLastTick = s.clock.Now()
+		s.phaseLastWall = s.clock.Wall()
+		s.phaseStarted = s.phaseLastWall
 		s.status = StatusRunning
 		return true
 	}
@@ -116,13 +153,13 @@ func (s *state) pause() (advanceDelta, bool) {
 	if s.status == StatusRunning {
 		delta := advanceDelta{}
 		if !s.phaseLastTick.IsZero() && !s.phaseLastWall.IsZero() {
-			delta = s.advance(elapsedSinceLastTick(s.phaseLastTick, s.phaseLastWall))
+			delta = s.advance(elapsedSinceLastTick(s.clock, s.phaseLastTick, s.phaseLastWall))
 		}
 		if s.status == StatusRunning {
 			s.status = StatusPaused
 		}
-		s.phaseLastTick = time.Now()
-		s.phaseLastWall = nowWallClock()
+		s.phaseLastTick = s.clock.Now()
+		s.phaseLastWall = s.clock.Wall()
 		return delta, true
 	}
 	return advanceDelta{}, false
@@ -130,8 +167,8 @@ func (s *state) pause() (advanceDelta, bool) {
 
 func (s *state) resume() bool {
 	if s.status == StatusPaused {
-		s.phaseLastTick = time.Now()
-		s.phaseLastWall = nowWallClock()
+		s.phaseLastTick = s.clock.Now()
+		s.phaseLastWall = s.clock.Wall()
 		s.status = StatusRunning
 		return true
 	}
@@ -139,37 +176,152 @@ func (s *state) resume() bool {
 }
 
 func (s *state) skipBreak() (advanceDelta, bool) {
+	if !s.phaseDetail().Kind.IsBreak() {
+		return advanceDelta{}, false
+	}
+	return s.skip()
+}
+
+// Ends the current phase early and moves on to the next one.
+// The completion records the time actually spent in the phase and is flagged as skipped.
+func (s *state) skip() (advanceDelta, bool) {
 	if s.status != StatusRunning && s.status != StatusPaused {
 		return advanceDelta{}, false
 	}
 
+	// Account for the time since the last tick first.
+	// If the phase ran out in the meantime it already completed naturally and there is nothing left to skip.
+	idx := s.phaseIdx
+	delta := advanceDelta{}
+	if s.status == StatusRunning {
+		delta = s.advance(elapsedSinceLastTick(s.clock, s.phaseLastTick, s.phaseLastWall))
+		s.phaseLastTick = s.clock.Now()
+		s.phaseLastWall = s.clock.Wall()
+		if delta.finished || s.phaseIdx != idx {
+			return delta, true
+		}
+	}
+
+	// A phase in overtime has run its full length, so ending it is not a skip.
 	phase := s.phaseDetail()
-	if phase.Kind != PhaseBreak {
+	nextIdx := s.phaseIdx + 1
+	completion := s.completion(phase, s.phaseElapsed, !s.overtime, s.clock.Wall())
+	completion.Final = nextIdx >= s.phaseCnt
+	delta.completions = append(delta.completions, completion)
+
+	if completion.Final {
+		s.status = StatusFinished
+		s.phaseElapsed = phase.Duration
+		s.overtime = false
+		delta.finished = true
+		return delta, true
+	}
+	s.phaseIdx = nextIdx
+	s.phaseElapsed = 0
+	s.phaseAdjust = 0
+	s.overtime = false
+	s.phaseLastTick = s.clock.Now()
+	s.phaseLastWall = s.clock.Wall()
+	s.phaseStarted = completion.EndedAt
+
+	return delta, true
+}
+
+// Lengthens (positive) or shortens (negative) the current phase.
+// The phase never becomes shorter than the time already spent in it.
+func (s *state) adjust(d time.Duration) (advanceDelta, bool) {
+	if s.status != StatusRunning && s.status != StatusPaused {
 		return advanceDelta{}, false
 	}
 
-	completion := phaseCompletion{
+	// Account for the time since the last tick so the floor uses the real elapsed time.
+	idx := s.phaseIdx
+	delta := advanceDelta{}
+	if s.status == StatusRunning {
+		delta = s.advance(elapsedSinceLastTick(s.clock, s.phaseLastTick, s.phaseLastWall))
+		s.phaseLastTick = s.clock.Now()
+		s.phaseLastWall = s.clock.Wall()
+		if delta.finished || s.phaseIdx != idx {
+			return delta, true
+		}
+	}
+
+	// Clamp at the elapsed time, but never lengthen a phase that is already in overtime by shortening it.
+	planned := s.plan[s.phaseIdx].Duration
+	floor := min(s.phaseElapsed-planned, s.phaseAdjust)
+	s.phaseAdjust = max(s.phaseAdjust+d, floor)
+	if s.overtime && s.phaseElapsed < s.phaseDetail().Duration {
+		s.overtime = false
+	}
+	return delta, true
+}
+
+// Ends a phase that is in overtime and moves on to the next one.
+// The completion records the overtime spent past the planned end.
+func (s *state) next() (advanceDelta, bool) {
+	if !s.overtime {
+		return advanceDelta{}, false
+	}
+	return s.skip()
+}
+
+// Build the completion record for the current phase, which ended at the wall clock time `endedAt`.
+func (s *state) completion(phase PhaseDetail, elapsed time.Duration, skipped bool, endedAt time.Time) phaseCompletion {
+	return phaseCompletion{
 		Phase: PhaseSnapshot{
 			Idx:       s.phaseIdx,
-			HumanIdx:  phaseHumanIdx(s.phaseIdx),
+			HumanIdx:  planHumanIdx(s.plan, s.phaseIdx),
 			Kind:      phase.Kind,
 			Duration:  phase.Duration,
-			Remaining: 0,
+			Remaining: phase.Duration - elapsed,
+			Label:     phase.Label,
 		},
+		Elapsed:   elapsed,
+		Overtime:  max(elapsed-phase.Duration, 0),
+		Skipped:   skipped,
+		StartedAt: s.phaseStarted,
+		EndedAt:   endedAt,
 	}
+}
 
-	nextIdx := s.phaseIdx + 1
-	s.phaseIdx = nextIdx
+// Discards all progress and returns to the first phase, waiting to be started.
+func (s *state) reset() bool {
+	if s.status == StatusInit && s.phaseIdx == 0 && s.phaseElapsed == 0 {
+		return false
+	}
+	s.phaseIdx = 0
 	s.phaseElapsed = 0
-	s.phaseLastTick = time.Now()
-	s.phaseLastWall = nowWallClock()
+	s.phaseAdjust = 0
+	s.overtime = false
+	s.phaseLastTick = time.Time{}
+	s.phaseLastWall = time.Time{}
+	s.phaseStarted = time.Time{}
+	s.status = StatusInit
+	return true
+}
 
-	return advanceDelta{completions: []phaseCompletion{completion}, finished: false}, true
+// Begins a new cycle from the first phase, optionally with a new plan.
+func (s *state) restart(plan []PhaseDetail) bool {
+	if plan != nil {
+		s.plan = plan
+		s.workPhases = planWorkPhases(plan)
+		s.phaseCnt = len(plan)
+	}
+	s.reset()
+	return s.start()
 }
 
+// Phases that end are dated from `phaseLastWall`, the wall clock time the elapsed time is measured from.
 func (s *state) advance(elapsed time.Duration) advanceDelta {
 	completions := make([]phaseCompletion, 0, 1)
+	consumed := time.Duration(0)
 	for elapsed > 0 {
+		// A phase in overtime keeps counting until the "next" command ends it.
+		if s.overtime {
+			s.phaseElapsed += elapsed
+			return advanceDelta{completions: completions, finished: false}
+		}
+
 		phase := s.phaseDetail()
 		phaseRemaining := phase.Duration - s.phaseElapsed
 		if elapsed < phaseRemaining {
@@ -177,14 +329,26 @@ func (s *state) advance(elapsed time.Duration) advanceDelta {
 			return advanceDelta{completions: completions, finished: false}
 		}
 
+		// Without auto advance the ended phase goes into overtime instead of rolling over.
+		if !s.autoAdvance {
+			s.phaseElapsed += elapsed
+			s.overtime = true
+			return advanceDelta{completions: completions, finished: false, overtime: true}
+		}
+
 		// From this point forward the phase we were tracking has already ended
 		// So:
 		// - Subtract the time that remained from that phase
 		// - Calculate the next index
 		// - Exit early if the next index is beyond the expected phase count (time is done)
 		elapsed -= phaseRemaining
+		consumed += phaseRemaining
+		endedAt := s.phaseLastWall.Add(consumed)
 		nextIdx := s.phaseIdx + 1
 		if nextIdx >= s.phaseCnt {
+			completion := s.completion(phase, phase.Duration, false, endedAt)
+			completion.Final = true
+			completions = append(completions, completion)
 			s.status = StatusFinished
 			s.phaseElapsed = phase.Duration
 			return advanceDelta{completions: completions, finished: true}
@@ -192,20 +356,14 @@ func (s *state) advance(elapsed time.Duration) advanceDelta {
 
 		// From this point forward we still have phases to complete,
 		// but we note that the previous phase has been completed
-		completions = append(completions, phaseCompletion{
-			Phase: PhaseSnapshot{
-				Idx:       s.phaseIdx,
-				HumanIdx:  phaseHumanIdx(s.phaseIdx),
-				Kind:      phase.Kind,
-				Duration:  phase.Duration,
-				Remaining: 0,
-			},
-		})
+		completions = append(completions, s.completion(phase, phase.Duration, false, endedAt))
 
 		// Update the phase index and reset the phase elapsed time to 0
 		// NOTE: The `elapsed` value that this loop tracks it not necessarily 0 at this point
 		s.phaseIdx = nextIdx
 		s.phaseElapsed = time.Duration(0)
+		s.phaseAdjust = time.Duration(0)
+		s.phaseStarted = endedAt
 	}
 	return advanceDelta{completions: completions, finished: s.status == StatusFinished}
 }
@@ -214,7 +372,9 @@ func (s *state) snapshot() stateSnapshot {
 	return stateSnapshot{
 		Phase:      s.phaseSnapshot(),
 		Status:     s.status,
+		Mode:       ModePomodoro,
 		WorkPhases: s.workPhases,
+		Plan:       s.plan,
 	}
 }
 
@@ -222,21 +382,57 @@ func (s *state) phaseSnapshot() PhaseSnapshot {
 	phase := s.phaseDetail()
 	return PhaseSnapshot{
 		Idx:       s.phaseIdx,
-		HumanIdx:  phaseHumanIdx(s.phaseIdx),
+		HumanIdx:  planHumanIdx(s.plan, s.phaseIdx),
 		Kind:      phase.Kind,
 		Duration:  phase.Duration,
 		Remaining: phase.Duration - s.phaseElapsed,
+		Label:     phase.Label,
+		Overtime:  s.overtime,
 	}
 }
 
+// Current phase from the plan, with its duration adjusted by any extension or shortening.
 func (s *state) phaseDetail() PhaseDetail {
-	detail := PhaseDetail{}
-	if s.phaseIdx%2 == 0 {
-		detail.Kind = PhaseWork
-		detail.Duration = s.workDur
-	} else {
-		detail.Kind = PhaseBreak
-		detail.Duration = s.breakDur
+	if s.phaseIdx < 0 || s.phaseIdx >= len(s.plan) {
+		return PhaseDetail{}
 	}
+	detail := s.plan[s.phaseIdx]
+	detail.Duration += s.phaseAdjust
 	return detail
 }
+
+func (s *state) session() Session {
+	return Session{
+		Mode:         ModePomodoro,
+		Plan:         s.plan,
+		PhaseIdx:     s.phaseIdx,
+		PhaseElapsed: s.phaseElapsed,
+		PhaseAdjust:  s.phaseAdjust,
+		Overtime:     s.overtime,
+		Status:       s.status,
+		StartedAt:    s.phaseStarted,
+		SavedAt:      s.phaseLastWall,
+	}
+}
+
+// Restores a saved session.
+// A running session keeps its saved wall clock timestamp, so the first tick sees the gap
+// as a sleep and catches up through `advance`.
+func (s *state) restore(session Session) {
+	if len(session.Plan) > 0 {
+		s.plan = session.Plan
+		s.workPhases = planWorkPhases(session.Plan)
+		s.phaseCnt = len(session.Plan)
+	}
+	if session.PhaseIdx < 0 || session.PhaseIdx >= s.phaseCnt {
+		return
+	}
+	s.phaseIdx = session.PhaseIdx
+	s.phaseElapsed = session.PhaseElapsed
+	s.phaseAdjust = session.PhaseAdjust
+	s.overtime = session.Overtime
+	s.status = session.Status
+	s.phaseLastTick = s.clock.Now()
+	s.phaseLastWall = session.SavedAt
+	s.phaseStarted = session.StartedAt
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func TestAdvanceSkipsPhasesOnLongElapsed(t *testing.T) {
-	s := newState(25*time.Minute, 5*time.Minute, 4)
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
 	if !s.start() {
 		t.Fatal("expected start to succeed")
 	}
@@ -33,7 +33,7 @@ func TestAdvanceSkipsPhasesOnLongElapsed(t *testing.T) {
 }
 
 func TestAdvanceFinishesOnVeryLongElapsed(t *testing.T) {
-	s := newState(25*time.Minute, 5*time.Minute, 4)
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
 	if !s.start() {
 		t.Fatal("expected start to succeed")
 	}
@@ -48,21 +48,24 @@ func TestAdvanceFinishesOnVeryLongElapsed(t *testing.T) {
 	if s.phaseIdx != 6 {
 		t.Fatalf("expected to finish on last phase index 6, got %d", s.phaseIdx)
 	}
-	if s.phaseElapsed != s.workDur {
+	if s.phaseElapsed != 25*time.Minute {
 		t.Fatalf("expected elapsed to equal full work duration, got %s", s.phaseElapsed)
 	}
-	if len(delta.completions) != 6 {
-		t.Fatalf("expected 6 phase completions before finishing, got %d", len(delta.completions))
+	if len(delta.completions) != 7 {
+		t.Fatalf("expected 7 phase completions including the final phase, got %d", len(delta.completions))
+	}
+	if last := delta.completions[6]; !last.Final || last.Phase.Idx != 6 {
+		t.Fatalf("expected the last completion to be the final phase 6, got idx=%d final=%v", last.Phase.Idx, last.Final)
 	}
 }
 
 func TestSkipBreakAdvancesToWork(t *testing.T) {
-	s := newState(25*time.Minute, 5*time.Minute, 4)
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
 	if !s.start() {
 		t.Fatal("expected start to succeed")
 	}
 
-	s.advance(s.workDur)
+	s.advance(25 * time.Minute)
 	if s.phaseDetail().Kind != PhaseBreak {
 		t.Fatalf("expected to be on break before skip, got %s", s.phaseDetail().Kind)
 	}
@@ -89,7 +92,7 @@ func TestSkipBreakAdvancesToWork(t *testing.T) {
 }
 
 func TestSkipBreakNoopDuringWork(t *testing.T) {
-	s := newState(25*time.Minute, 5*time.Minute, 4)
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
 	if !s.start() {
 		t.Fatal("expected start to succeed")
 	}
@@ -105,3 +108,280 @@ func TestSkipBreakNoopDuringWork(t *testing.T) {
 		t.Fatalf("expected to remain on work, got %s", s.phaseDetail().Kind)
 	}
 }
+
+func TestLongBreakReplacesEveryNthBreak(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 6, 2))
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+
+	wantKinds := []PhaseKind{
+		PhaseWork, PhaseBreak,
+		PhaseWork, PhaseLongBreak,
+		PhaseWork, PhaseBreak,
+		PhaseWork, PhaseLongBreak,
+	}
+	for idx, want := range wantKinds {
+		s.phaseIdx = idx
+		if got := s.phaseDetail().Kind; got != want {
+			t.Fatalf("expected phase %d to be %s, got %s", idx, want, got)
+		}
+	}
+
+	s.phaseIdx = 3
+	if s.phaseDetail().Duration != 15*time.Minute {
+		t.Fatalf("expected long break duration %s, got %s", 15*time.Minute, s.phaseDetail().Duration)
+	}
+}
+
+func TestSkipBreakSkipsLongBreak(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 1))
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+
+	s.advance(25 * time.Minute)
+	if s.phaseDetail().Kind != PhaseLongBreak {
+		t.Fatalf("expected to be on long break before skip, got %s", s.phaseDetail().Kind)
+	}
+
+	if _, skipped := s.skipBreak(); !skipped {
+		t.Fatal("expected skip to succeed during long break")
+	}
+	if s.phaseDetail().Kind != PhaseWork {
+		t.Fatalf("expected to land on work after skip, got %s", s.phaseDetail().Kind)
+	}
+}
+
+func TestAdvanceFollowsExplicitPlan(t *testing.T) {
+	plan := []PhaseDetail{
+		{Kind: PhaseWork, Duration: 50 * time.Minute},
+		{Kind: PhaseBreak, Duration: 10 * time.Minute},
+		{Kind: PhaseWork, Duration: 50 * time.Minute},
+		{Kind: PhaseBreak, Duration: 10 * time.Minute},
+		{Kind: PhaseWork, Duration: 90 * time.Minute, Label: "Deep work"},
+		{Kind: PhaseLongBreak, Duration: 30 * time.Minute},
+	}
+	s := newState(plan)
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+	if s.workPhases != 3 {
+		t.Fatalf("expected 3 work phases, got %d", s.workPhases)
+	}
+
+	delta := s.advance(130 * time.Minute)
+	if len(delta.completions) != 4 {
+		t.Fatalf("expected 4 phase completions, got %d", len(delta.completions))
+	}
+	snapshot := s.phaseSnapshot()
+	if snapshot.Idx != 4 || snapshot.HumanIdx != 3 || snapshot.Label != "Deep work" {
+		t.Fatalf("expected third work phase labelled Deep work, got idx=%d human=%d label=%q", snapshot.Idx, snapshot.HumanIdx, snapshot.Label)
+	}
+	if snapshot.Remaining != 80*time.Minute {
+		t.Fatalf("expected 80 minutes remaining, got %s", snapshot.Remaining)
+	}
+
+	delta = s.advance(120 * time.Minute)
+	if !delta.finished {
+		t.Fatal("expected timer to finish after the last phase")
+	}
+	if len(delta.completions) != 2 || delta.completions[0].Phase.Kind != PhaseWork || !delta.completions[1].Final {
+		t.Fatalf("expected the last work phase and the final long break to complete, got %+v", delta.completions)
+	}
+}
+
+func TestResetReturnsToInit(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+	s.advance(200 * time.Minute)
+	if s.status != StatusFinished {
+		t.Fatalf("expected status finished before reset, got %v", s.status)
+	}
+
+	transition := s.apply(command{kind: commandReset})
+	if !transition.EmitState {
+		t.Fatal("expected reset to emit state")
+	}
+	if transition.To.Status != StatusInit || transition.To.Phase.Idx != 0 {
+		t.Fatalf("expected init on phase 0, got status=%v idx=%d", transition.To.Status, transition.To.Phase.Idx)
+	}
+	if transition.To.Phase.Remaining != 25*time.Minute {
+		t.Fatalf("expected full work duration remaining, got %s", transition.To.Phase.Remaining)
+	}
+	if !s.start() {
+		t.Fatal("expected start to succeed after reset")
+	}
+}
+
+func TestRestartBeginsNewCycleWithNewPlan(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+	s.advance(200 * time.Minute)
+
+	plan := AlternatingPlan(50*time.Minute, 10*time.Minute, 30*time.Minute, 2, 0)
+	transition := s.apply(command{kind: commandRestart, plan: plan})
+	if !transition.EmitState {
+		t.Fatal("expected restart to emit state")
+	}
+	if transition.To.Status != StatusRunning || transition.To.Phase.Idx != 0 {
+		t.Fatalf("expected running on phase 0, got status=%v idx=%d", transition.To.Status, transition.To.Phase.Idx)
+	}
+	if transition.To.WorkPhases != 2 || transition.To.Phase.Duration != 50*time.Minute {
+		t.Fatalf("expected the new plan to apply, got work phases=%d duration=%s", transition.To.WorkPhases, transition.To.Phase.Duration)
+	}
+}
+
+func TestSkipEndsWorkEarlyWithActualElapsed(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+	s.advance(20 * time.Minute)
+	s.status = StatusPaused
+
+	delta, skipped := s.skip()
+	if !skipped {
+		t.Fatal("expected skip to succeed during work")
+	}
+	if len(delta.completions) != 1 {
+		t.Fatalf("expected 1 completion, got %d", len(delta.completions))
+	}
+	completion := delta.completions[0]
+	if !completion.Skipped || completion.Phase.Kind != PhaseWork {
+		t.Fatalf("expected a skipped work completion, got kind=%s skipped=%v", completion.Phase.Kind, completion.Skipped)
+	}
+	if completion.Elapsed != 20*time.Minute || completion.Phase.Duration != 25*time.Minute {
+		t.Fatalf("expected 20 of 25 minutes elapsed, got %s of %s", completion.Elapsed, completion.Phase.Duration)
+	}
+	if s.phaseDetail().Kind != PhaseBreak || s.status != StatusPaused {
+		t.Fatalf("expected to land on a paused break, got kind=%s status=%v", s.phaseDetail().Kind, s.status)
+	}
+}
+
+func TestSkipLastPhaseFinishes(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 1, 0))
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+	s.status = StatusPaused
+
+	delta, skipped := s.skip()
+	if !skipped || !delta.finished {
+		t.Fatal("expected skipping the last phase to finish the timer")
+	}
+	if s.status != StatusFinished {
+		t.Fatalf("expected status finished, got %v", s.status)
+	}
+}
+
+func TestExtendLengthensCurrentPhase(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+	s.advance(20 * time.Minute)
+	s.status = StatusPaused
+
+	transition := s.apply(command{kind: commandAdjust, duration: 5 * time.Minute})
+	if !transition.EmitState {
+		t.Fatal("expected extend to emit state")
+	}
+	if transition.To.Phase.Duration != 30*time.Minute || transition.To.Phase.Remaining != 10*time.Minute {
+		t.Fatalf("expected 30 minute phase with 10 remaining, got %s with %s", transition.To.Phase.Duration, transition.To.Phase.Remaining)
+	}
+
+	s.status = StatusRunning
+	delta := s.advance(10 * time.Minute)
+	if len(delta.completions) != 1 || delta.completions[0].Elapsed != 30*time.Minute {
+		t.Fatalf("expected the extended work phase to complete after 30 minutes, got %+v", delta.completions)
+	}
+	if s.phaseDetail().Duration != 5*time.Minute {
+		t.Fatalf("expected the next phase to keep its planned duration, got %s", s.phaseDetail().Duration)
+	}
+}
+
+func TestShortenNeverGoesBelowElapsed(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+	s.advance(20 * time.Minute)
+	s.status = StatusPaused
+
+	transition := s.apply(command{kind: commandAdjust, duration: -2 * time.Minute})
+	if transition.To.Phase.Duration != 23*time.Minute {
+		t.Fatalf("expected 23 minute phase after shortening, got %s", transition.To.Phase.Duration)
+	}
+
+	transition = s.apply(command{kind: commandAdjust, duration: -10 * time.Minute})
+	if transition.To.Phase.Duration != 20*time.Minute || transition.To.Phase.Remaining != 0 {
+		t.Fatalf("expected the phase to be clamped to the 20 minutes elapsed, got %s with %s remaining", transition.To.Phase.Duration, transition.To.Phase.Remaining)
+	}
+}
+
+func TestAdjustNoopBeforeStart(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+
+	transition := s.apply(command{kind: commandAdjust, duration: 5 * time.Minute})
+	if transition.EmitState {
+		t.Fatal("expected extend to be a no-op before start")
+	}
+	if s.phaseDetail().Duration != 25*time.Minute {
+		t.Fatalf("expected planned duration to be unchanged, got %s", s.phaseDetail().Duration)
+	}
+}
+
+func TestAdvanceEntersOvertimeWithoutAutoAdvance(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	s.autoAdvance = false
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+
+	delta := s.advance(27 * time.Minute)
+	if !delta.overtime {
+		t.Fatal("expected the work phase to enter overtime")
+	}
+	if len(delta.completions) != 0 {
+		t.Fatalf("expected no completions while in overtime, got %d", len(delta.completions))
+	}
+	delta = s.advance(time.Minute)
+	if delta.overtime {
+		t.Fatal("expected overtime to be reported only once")
+	}
+	snapshot := s.phaseSnapshot()
+	if s.phaseIdx != 0 || !snapshot.Overtime || snapshot.Remaining != -3*time.Minute {
+		t.Fatalf("expected phase 0 three minutes into overtime, got idx=%d overtime=%v remaining=%s", s.phaseIdx, snapshot.Overtime, snapshot.Remaining)
+	}
+
+	s.status = StatusPaused
+	transition := s.apply(command{kind: commandNext})
+	if len(transition.Completions) != 1 {
+		t.Fatalf("expected 1 completion, got %d", len(transition.Completions))
+	}
+	completion := transition.Completions[0]
+	if completion.Skipped || completion.Overtime != 3*time.Minute || completion.Elapsed != 28*time.Minute {
+		t.Fatalf("expected a natural completion with 3 minutes overtime, got skipped=%v overtime=%s elapsed=%s", completion.Skipped, completion.Overtime, completion.Elapsed)
+	}
+	if s.phaseIdx != 1 || s.overtime {
+		t.Fatalf("expected to land on phase 1 out of overtime, got idx=%d overtime=%v", s.phaseIdx, s.overtime)
+	}
+}
+
+func TestNextNoopOutsideOvertime(t *testing.T) {
+	s := newState(AlternatingPlan(25*time.Minute, 5*time.Minute, 15*time.Minute, 4, 0))
+	s.autoAdvance = false
+	if !s.start() {
+		t.Fatal("expected start to succeed")
+	}
+
+	transition := s.apply(command{kind: commandNext})
+	if transition.EmitState || len(transition.Completions) > 0 {
+		t.Fatal("expected next to be a no-op before the phase runs out")
+	}
+}
@@ -0,0 +1,40 @@
+package stats
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/diegoserranor/cadence/internal/history"
+)
+
+// Narrows records down before they are grouped. Empty fields match every record.
+type Filter struct {
+	// Only records carrying this tag.
+	Tag string
+	// Only records whose note contains this text, ignoring case.
+	Note string
+}
+
+func (f Filter) Match(record history.Record) bool {
+	if f.Tag != "" && !slices.Contains(record.Tags, f.Tag) {
+		return false
+	}
+	if f.Note != "" && !strings.Contains(strings.ToLower(record.Note), strings.ToLower(f.Note)) {
+		return false
+	}
+	return true
+}
+
+// Records matching `f`, in their original order.
+func (f Filter) Apply(records []history.Record) []history.Record {
+	if f == (Filter{}) {
+		return records
+	}
+	matched := make([]history.Record, 0, len(records))
+	for _, record := range records {
+		if f.Match(record) {
+			matched = append(matched, record)
+		}
+	}
+	return matched
+}
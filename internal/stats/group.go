@@ -0,0 +1,107 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/history"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// How records are grouped by `Group`.
+type GroupBy string
+
+const (
+	ByDay  GroupBy = "day"
+	ByWeek GroupBy = "week"
+	ByTag  GroupBy = "tag"
+	ByTask GroupBy = "task"
+)
+
+const (
+	// Key used by `ByTag` for work phases without tags.
+	Untagged = "untagged"
+	// Key used by `ByTask` for work phases done without an active task.
+	NoTask = "no task"
+)
+
+// Aggregated work phases sharing a key.
+type Bucket struct {
+	Key        string
+	WorkPhases int
+	Skipped    int
+	Focus      time.Duration
+	Overtime   time.Duration
+}
+
+func ParseGroupBy(value string) (GroupBy, error) {
+	switch GroupBy(value) {
+	case ByDay, ByWeek, ByTag, ByTask:
+		return GroupBy(value), nil
+	}
+	return "", fmt.Errorf("unknown grouping %q, expected day, week, tag or task", value)
+}
+
+// Aggregate work phases by calendar day, ISO week, tag or task, in `loc`.
+// Days and weeks are sorted chronologically, tags and tasks by focus time and then name.
+// A work phase with several tags counts towards each of them.
+func Group(records []history.Record, by GroupBy, loc *time.Location) []Bucket {
+	buckets := make([]Bucket, 0)
+	index := make(map[string]int)
+	add := func(key string, record history.Record) {
+		idx, ok := index[key]
+		if !ok {
+			idx = len(buckets)
+			index[key] = idx
+			buckets = append(buckets, Bucket{Key: key})
+		}
+		buckets[idx].WorkPhases++
+		buckets[idx].Focus += record.Actual
+		buckets[idx].Overtime += record.Overtime
+		if record.Skipped {
+			buckets[idx].Skipped++
+		}
+	}
+
+	for _, record := range records {
+		if record.Kind != pomodoro.PhaseWork {
+			continue
+		}
+		ended := record.EndedAt.In(loc)
+		switch by {
+		case ByWeek:
+			year, week := ended.ISOWeek()
+			add(fmt.Sprintf("%d-W%02d", year, week), record)
+		case ByTag:
+			if len(record.Tags) == 0 {
+				add(Untagged, record)
+			}
+			for _, tag := range record.Tags {
+				add(tag, record)
+			}
+		case ByTask:
+			if record.Task == "" {
+				add(NoTask, record)
+			} else {
+				add(record.Task, record)
+			}
+		default:
+			add(ended.Format(time.DateOnly), record)
+		}
+	}
+
+	if by == ByTag || by == ByTask {
+		sort.SliceStable(buckets, func(i, j int) bool {
+			if buckets[i].Focus != buckets[j].Focus {
+				return buckets[i].Focus > buckets[j].Focus
+			}
+			return buckets[i].Key < buckets[j].Key
+		})
+	} else {
+		sort.SliceStable(buckets, func(i, j int) bool {
+			return buckets[i].Key < buckets[j].Key
+		})
+	}
+	return buckets
+}
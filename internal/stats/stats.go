@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/history"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Work done on a single calendar day.
+type Day struct {
+	Date       time.Time
+	Focus      time.Duration
+	WorkPhases int
+}
+
+// Overview shown in the stats view.
+type Summary struct {
+	Today  Day
+	Week   []Day
+	Streak int
+}
+
+// Summarize the records relative to `now`, using `now`'s location for calendar days.
+// `Week` holds the last seven days, oldest first, ending today.
+func Summarize(records []history.Record, now time.Time) Summary {
+	today := dayStart(now)
+	from := today.AddDate(0, 0, -6)
+	week := Daily(records, from, today)
+	return Summary{
+		Today:  week[len(week)-1],
+		Week:   week,
+		Streak: Streak(records, now),
+	}
+}
+
+// Totals per calendar day from `from` through `to`, including days without work.
+// Only work phases count towards focus time, attributed to the day they ended.
+func Daily(records []history.Record, from, to time.Time) []Day {
+	loc := to.Location()
+	from = dayStart(from.In(loc))
+	to = dayStart(to)
+
+	days := make([]Day, 0)
+	index := make(map[time.Time]int)
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		index[date] = len(days)
+		days = append(days, Day{Date: date})
+	}
+
+	for _, record := range records {
+		if record.Kind != pomodoro.PhaseWork {
+			continue
+		}
+		idx, ok := index[dayStart(record.EndedAt.In(loc))]
+		if !ok {
+			continue
+		}
+		days[idx].Focus += record.Actual
+		days[idx].WorkPhases++
+	}
+	return days
+}
+
+// Number of consecutive days with at least one work phase, ending today.
+// A day without work yet does not break the streak until it is over.
+func Streak(records []history.Record, now time.Time) int {
+	worked := make(map[time.Time]bool)
+	for _, record := range records {
+		if record.Kind == pomodoro.PhaseWork {
+			worked[dayStart(record.EndedAt.In(now.Location()))] = true
+		}
+	}
+
+	day := dayStart(now)
+	if !worked[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	streak := 0
+	for worked[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+func dayStart(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,111 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/history"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+func workRecord(ended time.Time, actual time.Duration) history.Record {
+	return history.Record{
+		Kind:      pomodoro.PhaseWork,
+		Planned:   25 * time.Minute,
+		Actual:    actual,
+		StartedAt: ended.Add(-actual),
+		EndedAt:   ended,
+	}
+}
+
+func TestSummarizeTodayAndWeek(t *testing.T) {
+	now := time.Date(2026, 10, 17, 15, 0, 0, 0, time.UTC)
+	records := []history.Record{
+		workRecord(now.Add(-3*time.Hour), 25*time.Minute),
+		workRecord(now.Add(-2*time.Hour), 20*time.Minute),
+		{Kind: pomodoro.PhaseBreak, Actual: 5 * time.Minute, EndedAt: now.Add(-time.Hour)},
+		workRecord(now.AddDate(0, 0, -2), 50*time.Minute),
+		workRecord(now.AddDate(0, 0, -10), 25*time.Minute),
+	}
+
+	summary := Summarize(records, now)
+	if summary.Today.Focus != 45*time.Minute || summary.Today.WorkPhases != 2 {
+		t.Fatalf("expected 45 minutes over 2 work phases today, got %s over %d", summary.Today.Focus, summary.Today.WorkPhases)
+	}
+	if len(summary.Week) != 7 {
+		t.Fatalf("expected 7 days, got %d", len(summary.Week))
+	}
+	if summary.Week[4].Focus != 50*time.Minute || summary.Week[5].Focus != 0 {
+		t.Fatalf("expected focus two days ago only, got %s and %s", summary.Week[4].Focus, summary.Week[5].Focus)
+	}
+	if summary.Streak != 1 {
+		t.Fatalf("expected a streak of 1 after a gap yesterday, got %d", summary.Streak)
+	}
+}
+
+func TestStreakSurvivesUntilTheDayIsOver(t *testing.T) {
+	now := time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)
+	records := []history.Record{
+		workRecord(now.AddDate(0, 0, -1), 25*time.Minute),
+		workRecord(now.AddDate(0, 0, -2), 25*time.Minute),
+		workRecord(now.AddDate(0, 0, -3), 25*time.Minute),
+	}
+
+	if streak := Streak(records, now); streak != 3 {
+		t.Fatalf("expected a streak of 3 before working today, got %d", streak)
+	}
+}
+
+func TestGroupByWeekAndTag(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	tagged := workRecord(monday.AddDate(0, 0, 7), 50*time.Minute)
+	tagged.Tags = []string{"design", "review"}
+	records := []history.Record{
+		workRecord(monday, 25*time.Minute),
+		workRecord(monday.AddDate(0, 0, 6), 25*time.Minute),
+		tagged,
+	}
+
+	weeks := Group(records, ByWeek, time.UTC)
+	if len(weeks) != 2 || weeks[0].Key != "2026-W42" || weeks[0].WorkPhases != 2 || weeks[1].Focus != 50*time.Minute {
+		t.Fatalf("expected two ISO weeks, got %+v", weeks)
+	}
+
+	tags := Group(records, ByTag, time.UTC)
+	if len(tags) != 3 || tags[2].Key != Untagged || tags[2].Focus != 50*time.Minute {
+		t.Fatalf("expected design, review and untagged buckets, got %+v", tags)
+	}
+}
+
+func TestGroupByTask(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	report := workRecord(monday, 25*time.Minute)
+	report.Task = "Write report"
+	records := []history.Record{report, report, workRecord(monday, 25*time.Minute)}
+
+	byTask := Group(records, ByTask, time.UTC)
+	if len(byTask) != 2 || byTask[0].Key != "Write report" || byTask[0].WorkPhases != 2 || byTask[1].Key != NoTask {
+		t.Fatalf("expected the task before work without one, got %+v", byTask)
+	}
+}
+
+func TestFilterByTagAndNote(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	design := workRecord(monday, 25*time.Minute)
+	design.Tags = []string{"design"}
+	design.Note = "Sketched the Layout"
+	records := []history.Record{design, workRecord(monday, 25*time.Minute)}
+
+	if matched := (Filter{Tag: "design"}).Apply(records); len(matched) != 1 {
+		t.Fatalf("expected one record tagged design, got %+v", matched)
+	}
+	if matched := (Filter{Note: "layout"}).Apply(records); len(matched) != 1 || matched[0].Note != design.Note {
+		t.Fatalf("expected the note to match ignoring case, got %+v", matched)
+	}
+	if matched := (Filter{Tag: "design", Note: "review"}).Apply(records); len(matched) != 0 {
+		t.Fatalf("expected both conditions to apply, got %+v", matched)
+	}
+	if matched := (Filter{}).Apply(records); len(matched) != 2 {
+		t.Fatalf("expected an empty filter to keep everything, got %+v", matched)
+	}
+}
@@ -0,0 +1,179 @@
+// Package statusline renders the timer state as a single line for status bars such as tmux, polybar and waybar.
+package statusline
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Built-in formats. Anything else is parsed as a Go template over `Fields`.
+const (
+	FormatPlain  = "plain"
+	FormatWaybar = "waybar"
+	FormatTmux   = "tmux"
+)
+
+// Values available to templates, e.g. `{{.Kind}} {{.Remaining}}`.
+type Fields struct {
+	// Phase kind: "Work", "Break" or "Long break".
+	Kind  string
+	Label string
+	// Short name such as "Work 2/4", "Flow 1" or "Break 1", preferring the label when there is one.
+	Name string
+	// One of "ready", "running", "paused" or "finished".
+	Status     string
+	Mode       string
+	Phase      int
+	WorkPhases int
+	// Time left as "12:34". Flowtime work shows the time spent instead, and overtime is prefixed with "+".
+	Remaining string
+	// Seconds left in the phase; negative in overtime and during flowtime work.
+	Seconds int
+	// Progress through the phase from 0 to 100. Always 0 for flowtime work.
+	Percent  int
+	Overtime bool
+}
+
+// Renders a state as one line of output.
+type Formatter func(state pomodoro.EventStateChanged) (string, error)
+
+// Build the formatter for a built-in format name or a template.
+// An empty format is the same as "plain".
+func New(format string) (Formatter, error) {
+	var render func(Fields) (string, error)
+	switch format {
+	case "", FormatPlain:
+		render = func(f Fields) (string, error) { return Plain(f), nil }
+	case FormatWaybar:
+		render = waybar
+	case FormatTmux:
+		render = func(f Fields) (string, error) { return tmux(f), nil }
+	default:
+		tmpl, err := template.New("status").Parse(format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid format: %w", err)
+		}
+		render = func(f Fields) (string, error) {
+			var b strings.Builder
+			if err := tmpl.Execute(&b, f); err != nil {
+				return "", err
+			}
+			return b.String(), nil
+		}
+	}
+	return func(state pomodoro.EventStateChanged) (string, error) {
+		return render(FieldsFromState(state))
+	}, nil
+}
+
+// Derive the template fields from a state event.
+func FieldsFromState(state pomodoro.EventStateChanged) Fields {
+	phase := state.Phase
+	f := Fields{
+		Kind:       string(phase.Kind),
+		Label:      phase.Label,
+		Status:     state.Status.String(),
+		Mode:       string(state.Mode),
+		Phase:      phase.HumanIdx,
+		WorkPhases: state.WorkPhases,
+		Remaining:  formatClock(phase.Remaining),
+		Seconds:    int(phase.Remaining.Seconds()),
+		Overtime:   phase.Overtime,
+	}
+	if state.Status == pomodoro.StatusInit {
+		f.Status = "ready"
+	}
+	if phase.Overtime {
+		f.Remaining = "+" + f.Remaining
+	}
+	if phase.Duration > 0 {
+		f.Percent = min(100, int(100*(phase.Duration-max(phase.Remaining, 0))/phase.Duration))
+	}
+
+	name := f.Kind
+	if f.Label != "" {
+		name = f.Label
+	}
+	switch {
+	case state.Mode == pomodoro.ModeFlowtime && phase.Kind == pomodoro.PhaseWork:
+		f.Name = fmt.Sprintf("Flow %d", phase.HumanIdx)
+	case phase.Kind == pomodoro.PhaseWork && state.WorkPhases > 0:
+		f.Name = fmt.Sprintf("%s %d/%d", name, phase.HumanIdx, state.WorkPhases)
+	default:
+		f.Name = fmt.Sprintf("%s %d", name, phase.HumanIdx)
+	}
+	return f
+}
+
+// For example "Work 2/4 running 12:34", "Flow 1 paused 40:02" or "Break 1 overtime +1:15".
+func Plain(f Fields) string {
+	switch {
+	case f.Status == "finished":
+		return "finished"
+	case f.Overtime:
+		return fmt.Sprintf("%s overtime %s", f.Name, f.Remaining)
+	default:
+		return fmt.Sprintf("%s %s %s", f.Name, f.Status, f.Remaining)
+	}
+}
+
+// Waybar custom module output. Style the module with the `work`, `break`, `running`, `paused`, `overtime`, ... classes.
+type waybarOutput struct {
+	Text       string   `json:"text"`
+	Tooltip    string   `json:"tooltip"`
+	Class      []string `json:"class"`
+	Percentage int      `json:"percentage"`
+}
+
+func waybar(f Fields) (string, error) {
+	out := waybarOutput{
+		Text:       f.Remaining,
+		Tooltip:    Plain(f),
+		Class:      []string{className(f.Kind), f.Status},
+		Percentage: f.Percent,
+	}
+	if f.Overtime {
+		out.Class = append(out.Class, "overtime")
+	}
+	if f.Status == "finished" {
+		out.Text = "done"
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// tmux status-line markup, colored by phase and dimmed while paused.
+func tmux(f Fields) string {
+	color := "red"
+	if f.Kind != string(pomodoro.PhaseWork) {
+		color = "green"
+	}
+	switch {
+	case f.Status == "finished":
+		return "#[fg=green]done#[default]"
+	case f.Overtime:
+		color = "yellow"
+	case f.Status != "running":
+		color = "colour244"
+	}
+	return fmt.Sprintf("#[fg=%s]%s %s#[default]", color, f.Name, f.Remaining)
+}
+
+// "Long break" becomes "long-break" so it can be used as a CSS class.
+func className(kind string) string {
+	return strings.ReplaceAll(strings.ToLower(kind), " ", "-")
+}
+
+// Minutes and seconds of a duration, ignoring its sign.
+func formatClock(d time.Duration) string {
+	seconds := int(d.Abs().Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
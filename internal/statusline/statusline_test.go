@@ -0,0 +1,82 @@
+package statusline
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+func workState(remaining time.Duration, status pomodoro.TimerStatus) pomodoro.EventStateChanged {
+	return pomodoro.EventStateChanged{
+		Phase: pomodoro.PhaseSnapshot{
+			Idx:       2,
+			HumanIdx:  2,
+			Kind:      pomodoro.PhaseWork,
+			Duration:  25 * time.Minute,
+			Remaining: remaining,
+			Overtime:  remaining < 0,
+		},
+		Status:     status,
+		Mode:       pomodoro.ModePomodoro,
+		WorkPhases: 4,
+	}
+}
+
+func TestFormats(t *testing.T) {
+	state := workState(12*time.Minute+34*time.Second, pomodoro.StatusRunning)
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"", "Work 2/4 running 12:34"},
+		{FormatTmux, "#[fg=red]Work 2/4 12:34#[default]"},
+		{"{{.Kind}} {{.Remaining}} {{.Percent}}%", "Work 12:34 49%"},
+	}
+	for _, c := range cases {
+		formatter, err := New(c.format)
+		if err != nil {
+			t.Fatalf("New(%q): %v", c.format, err)
+		}
+		got, err := formatter(state)
+		if err != nil {
+			t.Fatalf("format %q: %v", c.format, err)
+		}
+		if got != c.want {
+			t.Fatalf("format %q = %q, want %q", c.format, got, c.want)
+		}
+	}
+
+	if _, err := New("{{.Kind"); err == nil {
+		t.Fatalf("expected an error for a malformed template")
+	}
+}
+
+func TestWaybarOvertime(t *testing.T) {
+	formatter, err := New(FormatWaybar)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	line, err := formatter(workState(-75*time.Second, pomodoro.StatusRunning))
+	if err != nil {
+		t.Fatalf("format: %v", err)
+	}
+
+	var out waybarOutput
+	if err := json.Unmarshal([]byte(line), &out); err != nil {
+		t.Fatalf("invalid JSON %q: %v", line, err)
+	}
+	if out.Text != "+1:15" || out.Tooltip != "Work 2/4 overtime +1:15" || out.Percentage != 100 {
+		t.Fatalf("unexpected output %+v", out)
+	}
+	want := []string{"work", "running", "overtime"}
+	if len(out.Class) != len(want) {
+		t.Fatalf("class = %v, want %v", out.Class, want)
+	}
+	for i := range want {
+		if out.Class[i] != want[i] {
+			t.Fatalf("class = %v, want %v", out.Class, want)
+		}
+	}
+}
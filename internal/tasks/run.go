@@ -0,0 +1,22 @@
+package tasks
+
+import (
+	"github.com/diegoserranor/cadence/internal/logs"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Counts every finished work phase from the machine's events towards the active task.
+// Work phases ended early with a skip count too, the same as in history and stats.
+func Run(events <-chan pomodoro.Event, source Source, appLogger logs.Logger) {
+	go func() {
+		for event := range events {
+			finished, ok := event.(pomodoro.EventPhaseFinished)
+			if !ok || finished.Phase.Kind != pomodoro.PhaseWork {
+				continue
+			}
+			if _, _, err := source.RecordPomodoro(); err != nil && appLogger != nil {
+				appLogger.Printf("task update failed: %v", err)
+			}
+		}
+	}()
+}
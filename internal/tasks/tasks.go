@@ -0,0 +1,245 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/xdg"
+)
+
+// Something worked on across pomodoros.
+// `Estimate` is how many work phases it was expected to take, `Actual` how many it did.
+type Task struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Estimate    int       `json:"estimate,omitempty"`
+	Actual      int       `json:"actual"`
+	Done        bool      `json:"done,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	CompletedAt time.Time `json:"completed_at,omitzero"`
+
+	// Only set for tasks read from todo.txt.
+	Priority string   `json:"priority,omitempty"`
+	Projects []string `json:"projects,omitempty"`
+	Contexts []string `json:"contexts,omitempty"`
+}
+
+// Contents of the tasks file.
+type List struct {
+	Tasks  []Task `json:"tasks"`
+	Active int    `json:"active,omitempty"`
+	NextID int    `json:"next_id"`
+}
+
+// Task with the given ID, if it exists.
+func (l List) Find(id int) (Task, bool) {
+	for _, task := range l.Tasks {
+		if task.ID == id {
+			return task, true
+		}
+	}
+	return Task{}, false
+}
+
+var ErrNotFound = errors.New("task not found")
+
+// Returned by a change passed to `Store.update` that left the list as it was, so nothing is written.
+var errNoChange = errors.New("no change")
+
+// Where tasks are kept, either cadence's own `Store` or a `TodoTxt` file.
+// Sources re-read their backing file on every call so a daemon and an attached TUI see each other's changes.
+type Source interface {
+	// Read every task.
+	Load() (List, error)
+	// Create a task. It becomes the active one when nothing else is.
+	Add(name string, estimate int) (Task, error)
+	// Make the task with `id` the one work phases are attributed to. An ID of 0 clears it.
+	SetActive(id int) error
+	// Mark the task with `id` as complete. Completing the active task clears it.
+	Complete(id int) error
+	// The active task, if any.
+	Active() (Task, bool, error)
+	// Count a finished work phase towards the active task.
+	// Returns the updated task, or false when no task is active.
+	RecordPomodoro() (Task, bool, error)
+}
+
+// JSON file of tasks and the active one.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	now  func() time.Time
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path, now: time.Now}
+}
+
+var _ Source = (*Store)(nil)
+
+// Location of the tasks file under the user's data directory.
+func Path() (string, error) {
+	dir, err := xdg.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cadence", "tasks.json"), nil
+}
+
+// Read every task. A missing file is an empty list.
+func (s *Store) Load() (List, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Create a task. It becomes the active one when nothing else is.
+func (s *Store) Add(name string, estimate int) (Task, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Task{}, errors.New("task name is empty")
+	}
+	if estimate < 0 {
+		return Task{}, errors.New("estimate must not be negative")
+	}
+
+	var task Task
+	err := s.update(func(list *List) error {
+		list.NextID++
+		task = Task{
+			ID:        list.NextID,
+			Name:      name,
+			Estimate:  estimate,
+			CreatedAt: s.now(),
+		}
+		list.Tasks = append(list.Tasks, task)
+		if list.Active == 0 {
+			list.Active = task.ID
+		}
+		return nil
+	})
+	return task, err
+}
+
+// Make the task with `id` the one work phases are attributed to. An ID of 0 clears it.
+func (s *Store) SetActive(id int) error {
+	return s.update(func(list *List) error {
+		if id == 0 {
+			list.Active = 0
+			return nil
+		}
+		task, ok := list.Find(id)
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrNotFound, id)
+		}
+		if task.Done {
+			return fmt.Errorf("task %q is already complete", task.Name)
+		}
+		list.Active = id
+		return nil
+	})
+}
+
+// Mark the task with `id` as complete. Completing the active task clears it.
+func (s *Store) Complete(id int) error {
+	return s.update(func(list *List) error {
+		for i := range list.Tasks {
+			if list.Tasks[i].ID != id {
+				continue
+			}
+			list.Tasks[i].Done = true
+			list.Tasks[i].CompletedAt = s.now()
+			if list.Active == id {
+				list.Active = 0
+			}
+			return nil
+		}
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	})
+}
+
+// The active task, if any.
+func (s *Store) Active() (Task, bool, error) {
+	list, err := s.Load()
+	if err != nil {
+		return Task{}, false, err
+	}
+	if list.Active == 0 {
+		return Task{}, false, nil
+	}
+	task, ok := list.Find(list.Active)
+	return task, ok, nil
+}
+
+// Count a finished work phase towards the active task.
+// Returns the updated task, or false when no task is active.
+func (s *Store) RecordPomodoro() (Task, bool, error) {
+	var task Task
+	var ok bool
+	err := s.update(func(list *List) error {
+		for i := range list.Tasks {
+			if list.Tasks[i].ID == list.Active {
+				list.Tasks[i].Actual++
+				task, ok = list.Tasks[i], true
+				return nil
+			}
+		}
+		// Without an active task there is nothing to count. Leave the file alone, or create none at all.
+		return errNoChange
+	})
+	return task, ok, err
+}
+
+func (s *Store) update(change func(*List) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err := change(&list); err != nil {
+		if errors.Is(err, errNoChange) {
+			return nil
+		}
+		return err
+	}
+	return s.save(list)
+}
+
+func (s *Store) load() (List, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return List{}, nil
+		}
+		return List{}, err
+	}
+	var list List
+	if err := json.Unmarshal(data, &list); err != nil {
+		return List{}, fmt.Errorf("parse %s: %w", s.path, err)
+	}
+	return list, nil
+}
+
+// Write through a temporary file so a reader never sees a partial list.
+func (s *Store) save(list List) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,91 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+func TestStoreTracksActiveTask(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "tasks.json"))
+
+	first, err := store.Add("Write report", 3)
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	second, err := store.Add("Review PR", 0)
+	if err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+	if active, ok, _ := store.Active(); !ok || active.ID != first.ID {
+		t.Fatalf("expected the first task to become active, got %+v", active)
+	}
+
+	if err := store.SetActive(second.ID); err != nil {
+		t.Fatalf("set active failed: %v", err)
+	}
+	if task, ok, err := store.RecordPomodoro(); err != nil || !ok || task.Actual != 1 {
+		t.Fatalf("expected a pomodoro on the second task, got %+v %v %v", task, ok, err)
+	}
+
+	if err := store.Complete(second.ID); err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+	if _, ok, _ := store.Active(); ok {
+		t.Fatal("expected completing the active task to clear it")
+	}
+	if _, ok, _ := store.RecordPomodoro(); ok {
+		t.Fatal("expected no task to count a pomodoro without an active task")
+	}
+	if err := store.SetActive(second.ID); err == nil {
+		t.Fatal("expected a completed task to be rejected as active")
+	}
+
+	// A second store on the same file sees the changes, like a daemon and an attached TUI.
+	list, err := NewStore(store.path).Load()
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(list.Tasks) != 2 || !list.Tasks[1].Done || list.Tasks[1].Actual != 1 {
+		t.Fatalf("expected both tasks back, got %+v", list.Tasks)
+	}
+}
+
+func TestRunCountsFinishedWorkPhases(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "tasks.json"))
+	if _, err := store.Add("Write report", 2); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	events := make(chan pomodoro.Event)
+	Run(events, store, nil)
+	work := pomodoro.PhaseSnapshot{Kind: pomodoro.PhaseWork}
+	events <- pomodoro.EventPhaseFinished{Phase: work, Elapsed: 25 * time.Minute}
+	events <- pomodoro.EventPhaseFinished{Phase: work, Skipped: true}
+	events <- pomodoro.EventPhaseFinished{Phase: pomodoro.PhaseSnapshot{Kind: pomodoro.PhaseBreak}}
+	// The unbuffered send returns once the previous event was handled.
+	events <- pomodoro.EventStateChanged{}
+	close(events)
+
+	task, _, err := store.Active()
+	// The skipped work phase counts, as it does in history; the break does not.
+	if err != nil || task.Actual != 2 {
+		t.Fatalf("expected two pomodoros counted, got %+v %v", task, err)
+	}
+}
+
+func TestRecordPomodoroWithoutActiveTaskWritesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	store := NewStore(path)
+
+	if _, ok, err := store.RecordPomodoro(); ok || err != nil {
+		t.Fatalf("expected nothing recorded, got %v %v", ok, err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected no tasks file, got %v", err)
+	}
+}
@@ -0,0 +1,368 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/diegoserranor/cadence/internal/xdg"
+)
+
+// Keys cadence reads and writes on todo.txt lines.
+const (
+	// Work phases completed on the task.
+	todoKeyPomodoros = "pomo"
+	// Pomodoros the task was expected to take.
+	todoKeyEstimate = "est"
+)
+
+var (
+	todoPriority = regexp.MustCompile(`^\(([A-Z])\) `)
+	todoDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `)
+	todoKeyValue = regexp.MustCompile(`^[^\s:]+:[^\s:]+$`)
+	todoWord     = regexp.MustCompile(`\S+`)
+)
+
+// Task source backed by a todo.txt file (see https://github.com/todotxt/todo.txt).
+// Tasks are identified by line number, as todo.sh does. cadence only touches the lines it changes:
+// completed work phases go in a `pomo:N` key and estimates in `est:N`.
+// todo.txt has no notion of an active task, so the active task's name and line are kept in a separate file.
+// The line tells apart open tasks with the same name; the name finds the task again when lines above it come or go.
+// Editing the active task's text in another tool loses it, and it has to be picked again.
+type TodoTxt struct {
+	mu         sync.Mutex
+	path       string
+	activePath string
+	now        func() time.Time
+}
+
+// Use the todo.txt file at `path`, remembering the active task in `activePath`.
+func NewTodoTxt(path, activePath string) *TodoTxt {
+	return &TodoTxt{path: path, activePath: activePath, now: time.Now}
+}
+
+// Location of the file remembering the active todo.txt task, under the user's data directory.
+func TodoTxtActivePath() (string, error) {
+	dir, err := xdg.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cadence", "todo-active.txt"), nil
+}
+
+var _ Source = (*TodoTxt)(nil)
+
+func (t *TodoTxt) Load() (List, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines, err := t.readLines()
+	if err != nil {
+		return List{}, err
+	}
+	return t.list(lines), nil
+}
+
+// Append a task to the file. It becomes the active one when nothing else is.
+func (t *TodoTxt) Add(name string, estimate int) (Task, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return Task{}, errors.New("task name is empty")
+	}
+	if estimate < 0 {
+		return Task{}, errors.New("estimate must not be negative")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines, err := t.readLines()
+	if err != nil {
+		return Task{}, err
+	}
+	line := t.now().Format(time.DateOnly) + " " + name
+	if estimate > 0 {
+		line = setTodoKey(line, todoKeyEstimate, strconv.Itoa(estimate))
+	}
+	lines = append(lines, line)
+	if err := t.writeLines(lines); err != nil {
+		return Task{}, err
+	}
+
+	task := parseTodo(len(lines), line)
+	if active, _ := t.readActive(); active.name == "" {
+		if err := t.writeActive(task); err != nil {
+			return Task{}, err
+		}
+	}
+	return task, nil
+}
+
+// Make the task on line `id` active. An ID of 0 clears it.
+func (t *TodoTxt) SetActive(id int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if id == 0 {
+		return t.writeActive(Task{})
+	}
+	lines, err := t.readLines()
+	if err != nil {
+		return err
+	}
+	task, ok := t.list(lines).Find(id)
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	if task.Done {
+		return fmt.Errorf("task %q is already complete", task.Name)
+	}
+	return t.writeActive(task)
+}
+
+// Mark the task on line `id` done the way todo.txt does: an `x` and the completion date replace the priority.
+func (t *TodoTxt) Complete(id int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines, err := t.readLines()
+	if err != nil {
+		return err
+	}
+	list := t.list(lines)
+	task, ok := list.Find(id)
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrNotFound, id)
+	}
+	if task.Done {
+		return nil
+	}
+
+	line := todoPriority.ReplaceAllString(lines[id-1], "")
+	lines[id-1] = "x " + t.now().Format(time.DateOnly) + " " + line
+	if err := t.writeLines(lines); err != nil {
+		return err
+	}
+	if list.Active == id {
+		return t.writeActive(Task{})
+	}
+	return nil
+}
+
+func (t *TodoTxt) Active() (Task, bool, error) {
+	list, err := t.Load()
+	if err != nil {
+		return Task{}, false, err
+	}
+	if list.Active == 0 {
+		return Task{}, false, nil
+	}
+	task, ok := list.Find(list.Active)
+	return task, ok, nil
+}
+
+// Count a finished work phase towards the active task by bumping its `pomo:N` key.
+func (t *TodoTxt) RecordPomodoro() (Task, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines, err := t.readLines()
+	if err != nil {
+		return Task{}, false, err
+	}
+	list := t.list(lines)
+	task, ok := list.Find(list.Active)
+	if !ok {
+		return Task{}, false, nil
+	}
+
+	lines[task.ID-1] = setTodoKey(lines[task.ID-1], todoKeyPomodoros, strconv.Itoa(task.Actual+1))
+	if err := t.writeLines(lines); err != nil {
+		return Task{}, false, err
+	}
+	return parseTodo(task.ID, lines[task.ID-1]), true, nil
+}
+
+// Parse every task line. The active task is the open task on the remembered line if its name still matches,
+// otherwise the first open task with the remembered name.
+func (t *TodoTxt) list(lines []string) List {
+	active, _ := t.readActive()
+	list := List{Tasks: make([]Task, 0, len(lines))}
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		task := parseTodo(i+1, line)
+		if active.name != "" && !task.Done && task.Name == active.name {
+			if list.Active == 0 || task.ID == active.line {
+				list.Active = task.ID
+			}
+		}
+		list.Tasks = append(list.Tasks, task)
+	}
+	list.NextID = len(lines) + 1
+	return list
+}
+
+// A missing file is an empty list.
+func (t *TodoTxt) readLines() ([]string, error) {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	text := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// Write through a temporary file next to the real one, so a crash or a full disk never leaves the list truncated.
+// A symlinked todo.txt is resolved first and the file it points at is replaced, keeping the link.
+func (t *TodoTxt) writeLines(lines []string) error {
+	path, err := filepath.EvalSymlinks(t.path)
+	if errors.Is(err, os.ErrNotExist) {
+		path = t.path
+	} else if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// The active task as last picked: its name, and the line it was on then.
+type todoActive struct {
+	name string
+	line int
+}
+
+// The file holds the name on its first line and the line number on the second.
+// Files written before the line was kept hold only the name.
+func (t *TodoTxt) readActive() (todoActive, error) {
+	data, err := os.ReadFile(t.activePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return todoActive{}, nil
+		}
+		return todoActive{}, err
+	}
+	name, line, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+	active := todoActive{name: strings.TrimSpace(name)}
+	active.line, _ = strconv.Atoi(strings.TrimSpace(line))
+	return active, nil
+}
+
+// Remember `task` as the active one. A task without a name clears it.
+func (t *TodoTxt) writeActive(task Task) error {
+	if task.Name == "" {
+		if err := os.Remove(t.activePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(t.activePath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(t.activePath, []byte(fmt.Sprintf("%s\n%d\n", task.Name, task.ID)), 0o644)
+}
+
+// Parse a todo.txt line: `x` and dates, a priority, then a description with +projects, @contexts and key:value pairs.
+// The task's name is the description without its key:value pairs.
+func parseTodo(id int, line string) Task {
+	task := Task{ID: id}
+	rest := strings.TrimSpace(line)
+
+	if strings.HasPrefix(rest, "x ") {
+		task.Done = true
+		rest = rest[2:]
+		if date := todoDate.FindString(rest); date != "" {
+			task.CompletedAt, _ = time.ParseInLocation(time.DateOnly, strings.TrimSpace(date), time.Local)
+			rest = rest[len(date):]
+		}
+	} else if match := todoPriority.FindStringSubmatch(rest); match != nil {
+		task.Priority = match[1]
+		rest = rest[len(match[0]):]
+	}
+	if date := todoDate.FindString(rest); date != "" {
+		task.CreatedAt, _ = time.ParseInLocation(time.DateOnly, strings.TrimSpace(date), time.Local)
+		rest = rest[len(date):]
+	}
+
+	words := make([]string, 0)
+	for _, word := range strings.Fields(rest) {
+		switch {
+		case len(word) > 1 && word[0] == '+':
+			task.Projects = append(task.Projects, word[1:])
+		case len(word) > 1 && word[0] == '@':
+			task.Contexts = append(task.Contexts, word[1:])
+		case isTodoKeyValue(word):
+			key, value, _ := strings.Cut(word, ":")
+			switch key {
+			case todoKeyPomodoros:
+				task.Actual, _ = strconv.Atoi(value)
+			case todoKeyEstimate:
+				task.Estimate, _ = strconv.Atoi(value)
+			}
+			continue
+		}
+		words = append(words, word)
+	}
+	task.Name = strings.Join(words, " ")
+	return task
+}
+
+// Whether `word` is a key:value pair. URLs such as https://example.com have the same shape but belong to the description.
+func isTodoKeyValue(word string) bool {
+	if !todoKeyValue.MatchString(word) {
+		return false
+	}
+	_, value, _ := strings.Cut(word, ":")
+	return !strings.HasPrefix(value, "//")
+}
+
+// Set `key:value` on a line, replacing the key's existing value or appending it.
+// The rest of the line, spacing included, is left as it was.
+func setTodoKey(line, key, value string) string {
+	for _, span := range todoWord.FindAllStringIndex(line, -1) {
+		word := line[span[0]:span[1]]
+		if k, _, _ := strings.Cut(word, ":"); k == key && isTodoKeyValue(word) {
+			return line[:span[0]] + key + ":" + value + line[span[1]:]
+		}
+	}
+	return strings.TrimRight(line, " ") + " " + key + ":" + value
+}
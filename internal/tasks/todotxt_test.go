@@ -0,0 +1,132 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseTodo(t *testing.T) {
+	task := parseTodo(3, "(A) 2026-10-01 Write report +cadence @desk due:2026-10-20 est:4 pomo:2")
+	if task.ID != 3 || task.Priority != "A" || task.Name != "Write report +cadence @desk" {
+		t.Fatalf("unexpected task %+v", task)
+	}
+	if task.Estimate != 4 || task.Actual != 2 || task.Done {
+		t.Fatalf("expected 2 of 4 pomodoros on an open task, got %+v", task)
+	}
+	if !slices.Equal(task.Projects, []string{"cadence"}) || !slices.Equal(task.Contexts, []string{"desk"}) {
+		t.Fatalf("expected the project and context, got %+v %+v", task.Projects, task.Contexts)
+	}
+	if task.CreatedAt.Format(time.DateOnly) != "2026-10-01" {
+		t.Fatalf("expected the creation date, got %v", task.CreatedAt)
+	}
+
+	// URLs stay in the name rather than being read as key:value pairs.
+	link := parseTodo(2, "Read https://example.com/post pomo:1")
+	if link.Name != "Read https://example.com/post" || link.Actual != 1 {
+		t.Fatalf("unexpected task with a link %+v", link)
+	}
+
+	done := parseTodo(1, "x 2026-10-16 2026-10-01 Review PR pomo:1")
+	if !done.Done || done.Name != "Review PR" || done.CompletedAt.Format(time.DateOnly) != "2026-10-16" {
+		t.Fatalf("unexpected completed task %+v", done)
+	}
+}
+
+func TestTodoTxtRecordsPomodorosOnTheActiveLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.txt")
+	content := "(B) Review PR +cadence\n\n(A) Write report @desk est:3\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	todo := NewTodoTxt(path, filepath.Join(dir, "active.txt"))
+	todo.now = func() time.Time { return time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC) }
+
+	// Line numbers are the IDs, blank lines included.
+	if err := todo.SetActive(3); err != nil {
+		t.Fatalf("set active failed: %v", err)
+	}
+	for range 2 {
+		if _, ok, err := todo.RecordPomodoro(); err != nil || !ok {
+			t.Fatalf("record failed: %v %v", ok, err)
+		}
+	}
+	if err := todo.Complete(3); err != nil {
+		t.Fatalf("complete failed: %v", err)
+	}
+	if _, err := todo.Add("Plan sprint", 2); err != nil {
+		t.Fatalf("add failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "(B) Review PR +cadence\n\nx 2026-10-17 Write report @desk est:3 pomo:2\n2026-10-17 Plan sprint est:2\n"
+	if string(data) != want {
+		t.Fatalf("unexpected todo.txt:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Completing the active task cleared it, so the added task took its place.
+	active, ok, err := todo.Active()
+	if err != nil || !ok || active.ID != 4 || active.Estimate != 2 {
+		t.Fatalf("expected the added task to be active, got %+v %v %v", active, ok, err)
+	}
+}
+
+func TestSetTodoKeyKeepsTheRestOfTheLine(t *testing.T) {
+	cases := []struct {
+		line string
+		want string
+	}{
+		{"Write  report\tpomo:1  @desk", "Write  report\tpomo:2  @desk"},
+		{"Read https://example.com", "Read https://example.com pomo:2"},
+		{"Write report ", "Write report pomo:2"},
+	}
+	for _, c := range cases {
+		if got := setTodoKey(c.line, todoKeyPomodoros, "2"); got != c.want {
+			t.Fatalf("setTodoKey(%q) = %q, want %q", c.line, got, c.want)
+		}
+	}
+}
+
+func TestTodoTxtKeepsSymlinkAndTellsDuplicatesApart(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "sync", "todo.txt")
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("Review PR\nReview PR\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "todo.txt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	todo := NewTodoTxt(link, filepath.Join(dir, "active.txt"))
+
+	// Two open tasks share a name; the second one is picked.
+	if err := todo.SetActive(2); err != nil {
+		t.Fatalf("set active failed: %v", err)
+	}
+	if task, ok, err := todo.RecordPomodoro(); err != nil || !ok || task.ID != 2 {
+		t.Fatalf("expected the second line counted, got %+v %v %v", task, ok, err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected the symlink kept, got %v %v", info, err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Review PR\nReview PR pomo:1\n"; string(data) != want {
+		t.Fatalf("unexpected todo.txt:\n%s\nwant:\n%s", data, want)
+	}
+	if info, err := os.Stat(target); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected the file mode kept, got %v %v", info, err)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/diegoserranor/cadence/internal/config"
+	"github.com/diegoserranor/cadence/internal/history"
 	"github.com/diegoserranor/cadence/internal/logs"
 	"github.com/diegoserranor/cadence/internal/pomodoro"
+	"github.com/diegoserranor/cadence/internal/tasks"
 	"github.com/diegoserranor/cadence/internal/tui/navigation"
 	"github.com/diegoserranor/cadence/internal/tui/views/configview"
 	"github.com/diegoserranor/cadence/internal/tui/views/defaultview"
+	"github.com/diegoserranor/cadence/internal/tui/views/statsview"
+	"github.com/diegoserranor/cadence/internal/tui/views/tasksview"
 )
 
 type model struct {
@@ -18,14 +22,20 @@ type model struct {
 	nav    navigation.Navigator
 }
 
-func newModel(machine *pomodoro.Machine, cfg config.Config, appLogger logs.Logger) model {
+func newModel(machine pomodoro.Controller, cfg config.Config, store *history.Store, taskSource tasks.Source, appLogger logs.Logger) model {
+	var notes *history.Store
+	if cfg.NotePrompt {
+		notes = store
+	}
 	return model{
 		logger: appLogger,
 		nav: navigation.New(
 			navigation.ViewID("default"),
 			map[navigation.ViewID]tea.Model{
-				navigation.ViewID("default"): defaultview.New(machine),
+				navigation.ViewID("default"): defaultview.New(machine, taskSource, notes),
 				navigation.ViewID("config"):  configview.New(cfg),
+				navigation.ViewID("stats"):   statsview.New(store),
+				navigation.ViewID("tasks"):   tasksview.New(taskSource),
 			}),
 	}
 }
@@ -50,16 +60,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 		return m, nil
+	case pomodoro.EventStateChanged, pomodoro.EventPhaseFinished, pomodoro.EventPhaseOvertime, pomodoro.EventTimerFinished:
+		// Machine events go to the timer view even while another view covers it,
+		// so a work phase that ends meanwhile still brings up the note prompt.
+		return m, m.updateView(navigation.ViewID("default"), msg)
 	}
 
-	currentID := m.nav.CurrentID()
-	if current := m.nav.Current(); current != nil {
-		updated, cmd := current.Update(msg)
-		// Persist the updated submodel in the navigator map; Bubble Tea returns a new model on Update.
-		m.nav.SetView(currentID, updated)
-		return m, cmd
+	return m, m.updateView(m.nav.CurrentID(), msg)
+}
+
+func (m model) updateView(id navigation.ViewID, msg tea.Msg) tea.Cmd {
+	view, ok := m.nav.View(id)
+	if !ok {
+		return nil
 	}
-	return m, nil
+	updated, cmd := view.Update(msg)
+	// Persist the updated submodel in the navigator map; Bubble Tea returns a new model on Update.
+	m.nav.SetView(id, updated)
+	return cmd
 }
 
 func (m model) View() string {
@@ -89,6 +89,12 @@ func (n *Navigator) Current() tea.Model {
 	return nil
 }
 
+// The view registered as `id`, whether or not it is shown.
+func (n *Navigator) View(id ViewID) (tea.Model, bool) {
+	view, ok := n.views[id]
+	return view, ok
+}
+
 func (n *Navigator) SetView(id ViewID, model tea.Model) {
 	if n.views == nil {
 		n.views = make(map[ViewID]tea.Model)
@@ -115,6 +121,10 @@ func (n *Navigator) Update(msg tea.Msg) (tea.Cmd, bool) {
 			return nil, true
 		}
 		n.stack = n.stack[:len(n.stack)-1]
+		// Views refresh in Init, so the view being returned to catches up on what changed while it was covered.
+		if current := n.Current(); current != nil {
+			return current.Init(), true
+		}
 		return nil, true
 	case ActionReplace:
 		if _, ok := n.views[navMsg.View]; !ok {
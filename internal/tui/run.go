@@ -6,17 +6,21 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/diegoserranor/cadence/internal/config"
+	"github.com/diegoserranor/cadence/internal/history"
 	"github.com/diegoserranor/cadence/internal/logs"
 	"github.com/diegoserranor/cadence/internal/pomodoro"
+	"github.com/diegoserranor/cadence/internal/tasks"
 )
 
-func Run(events <-chan pomodoro.Event, machine *pomodoro.Machine, cfg config.Config, appLogger logs.Logger) {
-	p := tea.NewProgram(newModel(machine, cfg, appLogger), tea.WithAltScreen())
+func Run(events <-chan pomodoro.Event, machine pomodoro.Controller, cfg config.Config, store *history.Store, taskSource tasks.Source, appLogger logs.Logger) {
+	p := tea.NewProgram(newModel(machine, cfg, store, taskSource, appLogger), tea.WithAltScreen())
 
 	go func() {
 		for event := range events {
 			p.Send(event)
 		}
+		// The stream only ends when the machine goes away, e.g. the daemon we attached to exited.
+		p.Quit()
 	}()
 
 	if _, err := p.Run(); err != nil {
@@ -3,6 +3,7 @@ package configview
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"strconv"
 	"strings"
 
@@ -13,25 +14,53 @@ import (
 )
 
 type Model struct {
-	config configState
-	form   *huh.Form
+	// Settings this run started with.
+	current config.Config
+	// The user config as written, which saving updates. Project files are left alone.
+	file config.Config
+	// Profile the form edits; empty for the base settings.
+	profile string
+	// Set while the profile picker is shown, before the settings form.
+	choosing bool
+	config   configState
+	form     *huh.Form
 }
 
 type configState struct {
-	workMinutes  string
-	phaseMinutes string
-	workPhases   string
+	workMinutes      string
+	phaseMinutes     string
+	workPhases       string
+	longBreakMinutes string
+	longBreakEvery   string
 }
 
 func New(cfg config.Config) *Model {
 	m := &Model{
-		config: configStateFromConfig(cfg),
+		current: cfg,
+		file:    cfg,
+		profile: cfg.Profile,
+		config:  configStateFromConfig(cfg),
 	}
 	m.initConfigForm()
 	return m
 }
 
+// Start with the profile picker when the config declares profiles, otherwise go straight to the settings.
 func (m *Model) Init() tea.Cmd {
+	if file, err := config.LoadUserFile(); err == nil {
+		m.file = file
+	}
+	m.profile = m.current.Profile
+	if len(m.file.Profiles) > 0 {
+		m.choosing = true
+		m.form = newProfileForm(&m.profile, m.file.ProfileNames())
+		return m.form.Init()
+	}
+	// Edit the base settings as the user config has them, not as this run resolved them
+	// with project files and command line flags.
+	m.profile = ""
+	m.config = configStateFromConfig(m.file)
+	m.choosing = false
 	m.initConfigForm()
 	return m.form.Init()
 }
@@ -52,8 +81,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.form = f
 	}
 
+	if m.form.State == huh.StateCompleted && m.choosing {
+		// Show the picked profile's settings for editing.
+		if resolved, err := m.file.WithProfile(m.profile); err == nil {
+			m.config = configStateFromConfig(resolved)
+		}
+		m.choosing = false
+		m.form = newConfigForm(&m.config)
+		return m, m.form.Init()
+	}
+
 	if m.form.State == huh.StateCompleted {
-		cfg, err := configFromState(m.config)
+		cfg, err := configFromState(m.file, m.profile, m.config)
 		if err != nil {
 			return m, tea.Batch(tea.Printf("invalid config: %v\n", err), tea.Quit)
 		}
@@ -77,6 +116,23 @@ func (m *Model) initConfigForm() {
 	m.form = newConfigForm(&m.config)
 }
 
+// Picks the profile to edit, which also becomes the one applied from the next start.
+func newProfileForm(profile *string, names []string) *huh.Form {
+	options := []huh.Option[string]{huh.NewOption("none (base settings)", "")}
+	for _, name := range names {
+		options = append(options, huh.NewOption(name, name))
+	}
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Profile").
+				Description("Used from the next start").
+				Options(options...).
+				Value(profile),
+		),
+	)
+}
+
 func newConfigForm(config *configState) *huh.Form {
 	return huh.NewForm(
 		huh.NewGroup(
@@ -92,6 +148,14 @@ func newConfigForm(config *configState) *huh.Form {
 				Title("Work phases").
 				Value(&config.workPhases).
 				Validate(validatePositiveInt),
+			huh.NewInput().
+				Title("Long break minutes").
+				Value(&config.longBreakMinutes).
+				Validate(validatePositiveInt),
+			huh.NewInput().
+				Title("Long break every (0 disables)").
+				Value(&config.longBreakEvery).
+				Validate(validateNonNegativeInt),
 		),
 	)
 }
@@ -104,15 +168,28 @@ func validatePositiveInt(value string) error {
 	return nil
 }
 
+func validateNonNegativeInt(value string) error {
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || parsed < 0 {
+		return errors.New("enter a whole number, 0 or greater")
+	}
+	return nil
+}
+
 func configStateFromConfig(cfg config.Config) configState {
 	return configState{
 		workMinutes:  strconv.Itoa(cfg.WorkMinutes),
 		phaseMinutes: strconv.Itoa(cfg.BreakMinutes),
 		workPhases:   strconv.Itoa(cfg.WorkPhases),
+
+		longBreakMinutes: strconv.Itoa(cfg.LongBreakMinutes),
+		longBreakEvery:   strconv.Itoa(cfg.LongBreakEvery),
 	}
 }
 
-func configFromState(state configState) (config.Config, error) {
+// Apply the form values over the base config, or over `profile` when one is picked, so settings the form does not edit,
+// such as an explicit phase plan, survive a save. The picked profile becomes the default.
+func configFromState(base config.Config, profile string, state configState) (config.Config, error) {
 	workMinutes, err := strconv.Atoi(strings.TrimSpace(state.workMinutes))
 	if err != nil {
 		return config.Config{}, fmt.Errorf("work minutes: %w", err)
@@ -125,10 +202,36 @@ func configFromState(state configState) (config.Config, error) {
 	if err != nil {
 		return config.Config{}, fmt.Errorf("work phases: %w", err)
 	}
+	longBreakMinutes, err := strconv.Atoi(strings.TrimSpace(state.longBreakMinutes))
+	if err != nil {
+		return config.Config{}, fmt.Errorf("long break minutes: %w", err)
+	}
+	longBreakEvery, err := strconv.Atoi(strings.TrimSpace(state.longBreakEvery))
+	if err != nil {
+		return config.Config{}, fmt.Errorf("long break every: %w", err)
+	}
 
-	return config.Config{
-		WorkMinutes:  workMinutes,
-		BreakMinutes: breakMinutes,
-		WorkPhases:   workPhases,
-	}, nil
+	cfg := base
+	cfg.Profile = profile
+	if profile == "" {
+		cfg.WorkMinutes = workMinutes
+		cfg.BreakMinutes = breakMinutes
+		cfg.WorkPhases = workPhases
+		cfg.LongBreakMinutes = longBreakMinutes
+		cfg.LongBreakEvery = longBreakEvery
+		return cfg, nil
+	}
+
+	cfg.Profiles = maps.Clone(base.Profiles)
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]config.Profile)
+	}
+	p := cfg.Profiles[profile]
+	p.WorkMinutes = workMinutes
+	p.BreakMinutes = breakMinutes
+	p.WorkPhases = workPhases
+	p.LongBreakMinutes = longBreakMinutes
+	p.LongBreakEvery = &longBreakEvery
+	cfg.Profiles[profile] = p
+	return cfg, nil
 }
@@ -8,7 +8,9 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/diegoserranor/cadence/internal/history"
 	"github.com/diegoserranor/cadence/internal/pomodoro"
+	"github.com/diegoserranor/cadence/internal/tasks"
 	"github.com/diegoserranor/cadence/internal/tui/navigation"
 )
 
@@ -17,8 +19,25 @@ type Model struct {
 	workPhases int
 	done       bool
 	status     pomodoro.TimerStatus
-	machine    *pomodoro.Machine
-	blinkOn    bool
+	mode       pomodoro.Mode
+	machine    pomodoro.Controller
+	tasks      tasks.Source
+	// Name of the task work phases are attributed to, if any.
+	task string
+	// Where tags and notes go after a work phase; nil when the prompt is off.
+	notes   *history.Store
+	prompt  *notePrompt
+	blinkOn bool
+	// Why the last command was rejected, shown until the next key press.
+	err error
+}
+
+type commandFailedMsg struct {
+	err error
+}
+
+type activeTaskMsg struct {
+	name string
 }
 
 var (
@@ -26,18 +45,43 @@ var (
 	indicatorHeight = 1
 )
 
+// How much `+` and `-` lengthen or shorten the current phase.
+const adjustStep = 5 * time.Minute
+
 const (
 	indicatorOn  = "█"
 	indicatorOff = "░"
 )
 
-func New(machine *pomodoro.Machine) *Model {
-	return &Model{machine: machine}
+// Pass a history store in `notes` to ask for tags and a note after each work phase, or nil to skip the prompt.
+func New(machine pomodoro.Controller, taskSource tasks.Source, notes *history.Store) *Model {
+	return &Model{machine: machine, tasks: taskSource, notes: notes}
 }
 
 func (m *Model) Init() tea.Cmd {
+	return tea.Batch(m.run(m.machine.GetState), m.loadActiveTask())
+}
+
+// Looks up the active task, which the tasks view or another instance may have changed.
+func (m *Model) loadActiveTask() tea.Cmd {
+	if m.tasks == nil {
+		return nil
+	}
 	return func() tea.Msg {
-		m.machine.GetState()
+		task, ok, err := m.tasks.Active()
+		if err != nil || !ok {
+			return activeTaskMsg{}
+		}
+		return activeTaskMsg{name: task.Name}
+	}
+}
+
+// Runs a machine command off the update loop and reports a rejection back to the view.
+func (m *Model) run(command func() error) tea.Cmd {
+	return func() tea.Msg {
+		if err := command(); err != nil {
+			return commandFailedMsg{err: err}
+		}
 		return nil
 	}
 }
@@ -45,37 +89,49 @@ func (m *Model) Init() tea.Cmd {
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.err = nil
+		if m.prompt != nil {
+			return m, m.updatePrompt(msg)
+		}
 		switch msg.String() {
 		case "q":
 			return m, tea.Quit
 		case "c":
 			return m, navigation.PushCmd(navigation.ViewID("config"))
+		case "t":
+			return m, navigation.PushCmd(navigation.ViewID("stats"))
+		case "w":
+			return m, navigation.PushCmd(navigation.ViewID("tasks"))
 		case "s":
-			return m, func() tea.Msg {
-				m.machine.Start()
-				return nil
-			}
+			return m, m.run(m.machine.Start)
 		case "p":
-			return m, func() tea.Msg {
-				m.machine.Pause()
-				return nil
-			}
+			return m, m.run(m.machine.Pause)
 		case "r":
-			return m, func() tea.Msg {
-				m.machine.Resume()
-				return nil
-			}
+			return m, m.run(m.machine.Resume)
 		case "k":
-			return m, func() tea.Msg {
-				m.machine.SkipBreak()
-				return nil
+			return m, m.run(m.machine.Skip)
+		case "+":
+			return m, m.run(func() error { return m.machine.Extend(adjustStep) })
+		case "-":
+			return m, m.run(func() error { return m.machine.Shorten(adjustStep) })
+		case "x":
+			return m, m.run(m.machine.Reset)
+		case "n":
+			if m.phase.Overtime {
+				return m, m.run(m.machine.Next)
 			}
+			if !m.done {
+				return m, nil
+			}
+			return m, m.run(func() error { return m.machine.Restart(nil) })
 		}
 	case pomodoro.EventStateChanged:
 		phaseChanged := msg.Phase.Idx != m.phase.Idx || msg.Phase.Kind != m.phase.Kind
 		m.phase = msg.Phase
 		m.status = msg.Status
+		m.mode = msg.Mode
 		m.workPhases = msg.WorkPhases
+		m.done = msg.Status == pomodoro.StatusFinished
 		if m.status == pomodoro.StatusRunning {
 			if phaseChanged {
 				m.blinkOn = true
@@ -86,19 +142,56 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.blinkOn = true
 		}
 		return m, nil
+	case pomodoro.EventPhaseFinished:
+		if m.notes != nil && m.prompt == nil && msg.Phase.Kind == pomodoro.PhaseWork {
+			m.prompt = newNotePrompt(msg)
+			return m, m.prompt.form.Init()
+		}
+		return m, nil
 	case pomodoro.EventTimerFinished:
 		m.done = true
 		return m, nil
+	case commandFailedMsg:
+		m.err = msg.err
+		return m, nil
+	case activeTaskMsg:
+		m.task = msg.name
+		return m, nil
+	}
+	// Anything else, such as the cursor blinking, belongs to the prompt's form.
+	if m.prompt != nil {
+		return m, m.updatePrompt(msg)
 	}
 	return m, nil
 }
 
 func (m *Model) View() string {
+	if m.prompt != nil {
+		return m.prompt.form.View() + "\n\n[esc] skip"
+	}
 	if m.done {
-		return "Nice job!\n\n[q] quit"
+		return "Nice job!\n\n[n] new cycle  [x] reset  [t] stats  [q] quit"
+	}
+	var indicator string
+	if m.mode == pomodoro.ModeFlowtime && m.phase.Kind == pomodoro.PhaseWork {
+		indicator = indicatorBox(fmt.Sprintf("flow %d", m.phase.HumanIdx))
+	} else {
+		indicator = renderPhaseIndicator(m.phase, m.status, m.workPhases, m.blinkOn)
 	}
-	indicator := renderPhaseIndicator(m.phase, m.status, m.workPhases, m.blinkOn)
-	return fmt.Sprintf("%s\n\n%s\n\n%s", renderRemaining(m.phase.Remaining), indicator, m.hints())
+	if m.phase.Kind == pomodoro.PhaseWork && m.phase.Label != "" {
+		indicator = fmt.Sprintf("%s\n%s", indicator, indicatorBox(m.phase.Label))
+	}
+	if m.phase.Overtime {
+		indicator = fmt.Sprintf("%s\n%s", indicator, indicatorBox("overtime"))
+	}
+	if m.task != "" {
+		indicator = fmt.Sprintf("%s\n\n%s", indicator, indicatorBox("task: "+m.task))
+	}
+	view := fmt.Sprintf("%s\n\n%s\n\n%s", renderRemaining(m.phase.Remaining), indicator, m.hints())
+	if m.err != nil {
+		view = fmt.Sprintf("%s\n\n%s", view, m.err)
+	}
+	return view
 }
 
 func (m *Model) hints() string {
@@ -111,18 +204,42 @@ func (m *Model) hints() string {
 	case pomodoro.StatusPaused:
 		hints = append(hints, "[r] resume")
 	}
-	if m.phase.Kind == pomodoro.PhaseBreak && (m.status == pomodoro.StatusRunning || m.status == pomodoro.StatusPaused) {
-		hints = append(hints, "[k] skip break")
+	if m.phase.Overtime {
+		hints = append(hints, "[n] next phase")
+	} else if m.status == pomodoro.StatusRunning || m.status == pomodoro.StatusPaused {
+		if m.phase.Kind.IsBreak() {
+			hints = append(hints, "[k] skip break")
+		} else if m.mode == pomodoro.ModeFlowtime {
+			hints = append(hints, "[k] take a break")
+		} else {
+			hints = append(hints, "[k] finish early")
+		}
+	}
+	if m.status == pomodoro.StatusRunning || m.status == pomodoro.StatusPaused {
+		// Flowtime work has no planned end to adjust.
+		if m.mode != pomodoro.ModeFlowtime || m.phase.Kind.IsBreak() {
+			hints = append(hints, "[+/-] 5 min")
+		}
+		hints = append(hints, "[x] reset")
 	}
 	hints = append(hints, "[c] config")
+	hints = append(hints, "[t] stats")
+	hints = append(hints, "[w] tasks")
 	hints = append(hints, "[q] quit")
 	return strings.Join(hints, "  ")
 }
 
 func renderPhaseIndicator(phase pomodoro.PhaseSnapshot, status pomodoro.TimerStatus, workPhases int, blinkOn bool) string {
 	// Breaks show a textual indicator instead of phase indicators.
-	if phase.Kind == pomodoro.PhaseBreak {
+	// A label from the phase plan replaces the generic text.
+	if phase.Kind.IsBreak() && phase.Label != "" {
+		return indicatorBox(strings.ToLower(phase.Label))
+	}
+	switch phase.Kind {
+	case pomodoro.PhaseBreak:
 		return indicatorBox(fmt.Sprintf("break %d", phase.HumanIdx))
+	case pomodoro.PhaseLongBreak:
+		return indicatorBox(fmt.Sprintf("long break %d", phase.HumanIdx))
 	}
 
 	// Work phases show indicators.
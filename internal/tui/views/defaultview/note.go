@@ -0,0 +1,64 @@
+package defaultview
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/diegoserranor/cadence/internal/history"
+	"github.com/diegoserranor/cadence/internal/pomodoro"
+)
+
+// Tags and a note jotted down after a work phase, saved with its history record.
+type notePrompt struct {
+	form      *huh.Form
+	tags      string
+	note      string
+	startedAt time.Time
+	endedAt   time.Time
+}
+
+func newNotePrompt(event pomodoro.EventPhaseFinished) *notePrompt {
+	p := &notePrompt{startedAt: event.StartedAt, endedAt: event.EndedAt}
+	p.form = huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Tags").
+				Description("Separated by commas or spaces").
+				Value(&p.tags),
+			huh.NewInput().
+				Title("Note").
+				Description("What did you get done?").
+				Value(&p.note),
+		),
+	)
+	return p
+}
+
+// Feeds `msg` to the prompt's form. Esc skips the prompt; completing the form saves the record.
+func (m *Model) updatePrompt(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "esc" {
+		m.prompt = nil
+		return nil
+	}
+
+	form, cmd := m.prompt.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.prompt.form = f
+	}
+	if m.prompt.form.State != huh.StateCompleted {
+		return cmd
+	}
+
+	prompt := m.prompt
+	m.prompt = nil
+	tags := history.ParseTags(prompt.tags)
+	note := strings.TrimSpace(prompt.note)
+	if len(tags) == 0 && note == "" {
+		return nil
+	}
+	return m.run(func() error {
+		return m.notes.Annotate(prompt.startedAt, prompt.endedAt, tags, note)
+	})
+}
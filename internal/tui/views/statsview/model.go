@@ -0,0 +1,197 @@
+package statsview
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/diegoserranor/cadence/internal/history"
+	"github.com/diegoserranor/cadence/internal/stats"
+	"github.com/diegoserranor/cadence/internal/tui/navigation"
+)
+
+type Model struct {
+	store   *history.Store
+	summary stats.Summary
+	loaded  bool
+	err     error
+	// Narrows the records down before they are summarized. Kept while the view comes and goes.
+	filter stats.Filter
+	// Set while the filter form is shown.
+	form  *huh.Form
+	draft stats.Filter
+}
+
+type summaryLoadedMsg struct {
+	summary stats.Summary
+	err     error
+}
+
+const (
+	barWidth = 20
+	barOn    = "█"
+	barOff   = "░"
+)
+
+func New(store *history.Store) *Model {
+	return &Model{store: store}
+}
+
+// Reload the history every time the view is shown.
+func (m *Model) Init() tea.Cmd {
+	m.form = nil
+	return m.load()
+}
+
+func (m *Model) load() tea.Cmd {
+	m.loaded = false
+	filter := m.filter
+	return func() tea.Msg {
+		if m.store == nil {
+			return summaryLoadedMsg{err: fmt.Errorf("history unavailable")}
+		}
+		records, err := m.store.Load()
+		records = filter.Apply(records)
+		return summaryLoadedMsg{summary: stats.Summarize(records, time.Now()), err: err}
+	}
+}
+
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if loaded, ok := msg.(summaryLoadedMsg); ok {
+		m.summary = loaded.summary
+		m.err = loaded.err
+		m.loaded = true
+		return m, nil
+	}
+	if m.form != nil {
+		return m.updateForm(msg)
+	}
+
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "esc":
+			return m, navigation.PopCmd()
+		case "q":
+			return m, tea.Quit
+		case "f":
+			m.draft = m.filter
+			m.form = newFilterForm(&m.draft)
+			return m, m.form.Init()
+		}
+	}
+	return m, nil
+}
+
+// Feeds `msg` to the filter form. Esc keeps the current filter; completing the form applies the new one.
+func (m *Model) updateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "esc" {
+		m.form = nil
+		return m, nil
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+	if m.form.State != huh.StateCompleted {
+		return m, cmd
+	}
+
+	m.form = nil
+	m.filter = stats.Filter{
+		Tag:  strings.TrimPrefix(strings.TrimSpace(m.draft.Tag), "#"),
+		Note: strings.TrimSpace(m.draft.Note),
+	}
+	return m, m.load()
+}
+
+func (m *Model) View() string {
+	if m.form != nil {
+		return m.form.View() + "\n\n[esc] cancel"
+	}
+
+	hints := "[f] filter  [esc] back  [q] quit"
+	if !m.loaded {
+		return "Loading stats...\n\n" + hints
+	}
+	if m.err != nil {
+		return fmt.Sprintf("Could not load stats: %v\n\n%s", m.err, hints)
+	}
+
+	today := fmt.Sprintf(
+		"Today  %s focused  %d work phases  %s",
+		formatFocus(m.summary.Today.Focus),
+		m.summary.Today.WorkPhases,
+		formatStreak(m.summary.Streak),
+	)
+	view := fmt.Sprintf("%s\n\n%s\n\n%s", today, renderWeek(m.summary.Week), hints)
+	if m.filter != (stats.Filter{}) {
+		view = formatFilter(m.filter) + "\n\n" + view
+	}
+	return view
+}
+
+func newFilterForm(draft *stats.Filter) *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Tag").
+				Description("Only work phases with this tag. Blank for any").
+				Value(&draft.Tag),
+			huh.NewInput().
+				Title("Note").
+				Description("Only work phases whose note contains this text. Blank for any").
+				Value(&draft.Note),
+		),
+	)
+}
+
+func formatFilter(filter stats.Filter) string {
+	parts := make([]string, 0, 2)
+	if filter.Tag != "" {
+		parts = append(parts, "tag #"+filter.Tag)
+	}
+	if filter.Note != "" {
+		parts = append(parts, fmt.Sprintf("note %q", filter.Note))
+	}
+	return "Filtered by " + strings.Join(parts, ", ")
+}
+
+// One horizontal bar per day, scaled to the busiest day of the week.
+func renderWeek(week []stats.Day) string {
+	var most time.Duration
+	for _, day := range week {
+		most = max(most, day.Focus)
+	}
+
+	lines := make([]string, 0, len(week))
+	for _, day := range week {
+		filled := 0
+		if most > 0 {
+			filled = int(float64(barWidth) * float64(day.Focus) / float64(most))
+		}
+		if day.Focus > 0 && filled == 0 {
+			filled = 1
+		}
+		bar := strings.Repeat(barOn, filled) + strings.Repeat(barOff, barWidth-filled)
+		lines = append(lines, fmt.Sprintf("%s  %s  %s", day.Date.Format("Mon"), bar, formatFocus(day.Focus)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatFocus(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+func formatStreak(days int) string {
+	if days == 1 {
+		return "1 day streak"
+	}
+	return fmt.Sprintf("%d day streak", days)
+}
@@ -0,0 +1,243 @@
+package tasksview
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/huh"
+	"github.com/diegoserranor/cadence/internal/tasks"
+	"github.com/diegoserranor/cadence/internal/tui/navigation"
+)
+
+type Model struct {
+	source tasks.Source
+	list   tasks.List
+	cursor int
+	loaded bool
+	err    error
+	// Set while the new task form is shown.
+	form  *huh.Form
+	draft draft
+}
+
+type draft struct {
+	name     string
+	estimate string
+}
+
+type listLoadedMsg struct {
+	list tasks.List
+	err  error
+}
+
+const (
+	markerActive = "●"
+	markerDone   = "✓"
+)
+
+func New(source tasks.Source) *Model {
+	return &Model{source: source}
+}
+
+// Reload the tasks every time the view is shown, since another instance may have changed them.
+func (m *Model) Init() tea.Cmd {
+	m.loaded = false
+	m.form = nil
+	return m.change(nil)
+}
+
+// Runs `op` against the task source off the update loop, then reloads the list.
+func (m *Model) change(op func(tasks.Source) error) tea.Cmd {
+	return func() tea.Msg {
+		if m.source == nil {
+			return listLoadedMsg{err: fmt.Errorf("tasks unavailable")}
+		}
+		var opErr error
+		if op != nil {
+			opErr = op(m.source)
+		}
+		list, err := m.source.Load()
+		if opErr != nil {
+			err = opErr
+		}
+		return listLoadedMsg{list: list, err: err}
+	}
+}
+
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if loaded, ok := msg.(listLoadedMsg); ok {
+		m.list = loaded.list
+		m.err = loaded.err
+		m.loaded = true
+		m.cursor = min(m.cursor, max(len(m.list.Tasks)-1, 0))
+		return m, nil
+	}
+	if m.form != nil {
+		return m.updateForm(msg)
+	}
+
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "esc":
+		return m, navigation.PopCmd()
+	case "q":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = min(m.cursor+1, max(len(m.list.Tasks)-1, 0))
+	case "a":
+		m.draft = draft{}
+		m.form = newTaskForm(&m.draft)
+		return m, m.form.Init()
+	case "enter":
+		if task, ok := m.selected(); ok {
+			id := task.ID
+			if id == m.list.Active {
+				// Selecting the active task again stops tracking it.
+				id = 0
+			}
+			return m, m.change(func(source tasks.Source) error { return source.SetActive(id) })
+		}
+	case "d":
+		if task, ok := m.selected(); ok && !task.Done {
+			return m, m.change(func(source tasks.Source) error { return source.Complete(task.ID) })
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) updateForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "esc" {
+		m.form = nil
+		return m, nil
+	}
+
+	form, cmd := m.form.Update(msg)
+	if f, ok := form.(*huh.Form); ok {
+		m.form = f
+	}
+	if m.form.State != huh.StateCompleted {
+		return m, cmd
+	}
+
+	m.form = nil
+	name := m.draft.name
+	estimate, _ := strconv.Atoi(strings.TrimSpace(m.draft.estimate))
+	return m, m.change(func(source tasks.Source) error {
+		_, err := source.Add(name, estimate)
+		return err
+	})
+}
+
+func (m *Model) selected() (tasks.Task, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.list.Tasks) {
+		return tasks.Task{}, false
+	}
+	return m.list.Tasks[m.cursor], true
+}
+
+func (m *Model) View() string {
+	if m.form != nil {
+		return m.form.View() + "\n\n[esc] cancel"
+	}
+
+	hints := "[a] add  [enter] select  [d] complete  [esc] back  [q] quit"
+	if !m.loaded {
+		return "Loading tasks...\n\n" + hints
+	}
+
+	var body string
+	if len(m.list.Tasks) == 0 {
+		body = "No tasks yet."
+	} else {
+		body = renderTasks(m.list, m.cursor)
+	}
+	view := fmt.Sprintf("Tasks\n\n%s\n\n%s", body, hints)
+	if m.err != nil {
+		view = fmt.Sprintf("%s\n\n%s", view, m.err)
+	}
+	return view
+}
+
+// One line per task: cursor, marker, priority and name, and pomodoros done against the estimate.
+func renderTasks(list tasks.List, cursor int) string {
+	width := 0
+	for _, task := range list.Tasks {
+		width = max(width, len([]rune(taskTitle(task))))
+	}
+
+	lines := make([]string, 0, len(list.Tasks))
+	for i, task := range list.Tasks {
+		pointer := " "
+		if i == cursor {
+			pointer = ">"
+		}
+		marker := " "
+		switch {
+		case task.Done:
+			marker = markerDone
+		case task.ID == list.Active:
+			marker = markerActive
+		}
+		title := taskTitle(task)
+		name := title + strings.Repeat(" ", width-len([]rune(title)))
+		lines = append(lines, fmt.Sprintf("%s %s %s  %s", pointer, marker, name, formatProgress(task)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Name of the task, led by its todo.txt priority if it has one.
+func taskTitle(task tasks.Task) string {
+	if task.Priority == "" {
+		return task.Name
+	}
+	return fmt.Sprintf("(%s) %s", task.Priority, task.Name)
+}
+
+func formatProgress(task tasks.Task) string {
+	if task.Estimate == 0 {
+		return fmt.Sprintf("%d pomodoros", task.Actual)
+	}
+	return fmt.Sprintf("%d/%d pomodoros", task.Actual, task.Estimate)
+}
+
+func newTaskForm(d *draft) *huh.Form {
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Task").
+				Value(&d.name).
+				Validate(validateName),
+			huh.NewInput().
+				Title("Estimated pomodoros (blank for none)").
+				Value(&d.estimate).
+				Validate(validateEstimate),
+		),
+	)
+}
+
+func validateName(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("enter a name")
+	}
+	return nil
+}
+
+func validateEstimate(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		return errors.New("enter a whole number, 0 or greater")
+	}
+	return nil
+}
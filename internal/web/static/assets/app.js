@@ -0,0 +1,186 @@
+// Dashboard client. Mirrors the TUI's default view: countdown, phase indicators and the same controls.
+"use strict";
+
+const indicatorOn = "█";
+const indicatorOff = "░";
+const adjustSeconds = 5 * 60;
+
+const view = {
+  state: null,
+  done: false,
+  blinkOn: true,
+};
+
+const $ = (id) => document.getElementById(id);
+
+// Runs a command. A rejected command shows the server's `{"error":"..."}` under the controls until the next one succeeds.
+async function send(command, query = "") {
+  let message = "";
+  try {
+    const response = await fetch(`/${command}${query}`, { method: "POST" });
+    if (!response.ok) {
+      const body = await response.json().catch(() => ({}));
+      message = body.error || `${command} failed: ${response.status} ${response.statusText}`;
+    }
+  } catch (err) {
+    message = `${command} failed: ${err.message}`;
+  }
+  $("error").textContent = message;
+  $("error").hidden = message === "";
+}
+
+// Durations arrive in nanoseconds. Overtime and flowtime work are negative and shown without the sign.
+function formatRemaining(ns) {
+  const total = Math.abs(Math.trunc(ns / 1e9));
+  const minutes = Math.floor(total / 60);
+  const seconds = String(total % 60).padStart(2, "0");
+  return `${minutes}:${seconds}`;
+}
+
+function isBreak(kind) {
+  return kind === "Break" || kind === "Long break";
+}
+
+function indicatorText(state, blinkOn) {
+  const phase = state.phase;
+  if (state.mode === "flowtime" && phase.kind === "Work") {
+    return `flow ${phase.human_idx}`;
+  }
+  if (isBreak(phase.kind)) {
+    if (phase.label) {
+      return phase.label.toLowerCase();
+    }
+    return phase.kind === "Long break" ? `long break ${phase.human_idx}` : `break ${phase.human_idx}`;
+  }
+
+  const count = state.work_phases > 0 ? state.work_phases : 1;
+  const indicators = [];
+  const activeIdx = phase.human_idx - 1;
+  for (let i = 0; i < count; i++) {
+    if (state.status === "init") {
+      indicators.push(indicatorOff);
+    } else if (i === activeIdx) {
+      indicators.push(state.status === "running" && !blinkOn ? indicatorOff : indicatorOn);
+    } else {
+      indicators.push(i < activeIdx ? indicatorOn : indicatorOff);
+    }
+  }
+  return indicators.join(" ");
+}
+
+// Same controls and keys as the TUI hints.
+function controls(state, done) {
+  if (done) {
+    return [
+      ["n", "new cycle", () => send("restart")],
+      ["x", "reset", () => send("reset")],
+    ];
+  }
+
+  const phase = state.phase;
+  const active = state.status === "running" || state.status === "paused";
+  const list = [];
+  if (state.status === "init") {
+    list.push(["s", "start", () => send("start")]);
+  } else if (state.status === "running") {
+    list.push(["p", "pause", () => send("pause")]);
+  } else if (state.status === "paused") {
+    list.push(["r", "resume", () => send("resume")]);
+  }
+  if (phase.overtime) {
+    list.push(["n", "next phase", () => send("next")]);
+  } else if (active) {
+    let text = "finish early";
+    if (isBreak(phase.kind)) {
+      text = "skip break";
+    } else if (state.mode === "flowtime") {
+      text = "take a break";
+    }
+    list.push(["k", text, () => send("skip")]);
+  }
+  if (active) {
+    // Flowtime work has no planned end to adjust.
+    if (state.mode !== "flowtime" || isBreak(phase.kind)) {
+      list.push(["+", "5 min", () => send("extend", `?seconds=${adjustSeconds}`)]);
+      list.push(["-", "5 min", () => send("shorten", `?seconds=${adjustSeconds}`)]);
+    }
+    list.push(["x", "reset", () => send("reset")]);
+  }
+  return list;
+}
+
+function render() {
+  const state = view.state;
+  if (!state) {
+    return;
+  }
+  const phase = state.phase;
+
+  $("timer").hidden = view.done;
+  $("done").hidden = !view.done;
+
+  const classes = [isBreak(phase.kind) ? "break" : "work", state.status === "init" ? "ready" : state.status];
+  if (phase.overtime) {
+    classes.push("overtime");
+  }
+  document.body.className = classes.join(" ");
+
+  $("remaining").textContent = formatRemaining(phase.remaining);
+  $("indicator").textContent = indicatorText(state, view.blinkOn);
+  $("label").hidden = !(phase.kind === "Work" && phase.label);
+  $("label").textContent = phase.label || "";
+  $("overtime").hidden = !phase.overtime;
+
+  const nav = $("controls");
+  nav.replaceChildren();
+  for (const [key, text, action] of controls(state, view.done)) {
+    const button = document.createElement("button");
+    button.dataset.key = key;
+    button.innerHTML = `<kbd>[${key}]</kbd> ${text}`;
+    button.addEventListener("click", action);
+    nav.appendChild(button);
+  }
+
+  const title = view.done ? "done" : formatRemaining(phase.remaining);
+  document.title = `${title} · cadence`;
+}
+
+function onState(state) {
+  const previous = view.state;
+  const phaseChanged = !previous || previous.phase.idx !== state.phase.idx || previous.phase.kind !== state.phase.kind;
+  if (state.status === "running") {
+    view.blinkOn = phaseChanged ? true : !view.blinkOn;
+  } else {
+    view.blinkOn = true;
+  }
+  view.state = state;
+  view.done = state.status === "finished";
+  render();
+}
+
+function connect() {
+  const events = new EventSource("/events");
+  events.addEventListener("open", () => {
+    $("connection").textContent = "";
+  });
+  events.addEventListener("error", () => {
+    $("connection").textContent = "disconnected, retrying...";
+  });
+  events.addEventListener("state_changed", (event) => onState(JSON.parse(event.data)));
+  events.addEventListener("timer_finished", () => {
+    view.done = true;
+    render();
+  });
+}
+
+document.addEventListener("keydown", (event) => {
+  if (event.ctrlKey || event.metaKey || event.altKey) {
+    return;
+  }
+  const button = document.querySelector(`button[data-key="${CSS.escape(event.key)}"]`);
+  if (button) {
+    button.click();
+  }
+});
+
+connect();
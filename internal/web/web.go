@@ -0,0 +1,28 @@
+// Package web serves the browser dashboard, a single page mirroring the TUI's default view.
+// The page is embedded in the binary and talks to the HTTP API from `internal/control`.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var files embed.FS
+
+// Serve the dashboard at `/` and its assets under `/assets/`, passing every other request to `api`.
+func Handler(api http.Handler) http.Handler {
+	static, err := fs.Sub(files, "static")
+	if err != nil {
+		// The directory is embedded at build time, so this cannot happen.
+		panic(err)
+	}
+	fileServer := http.FileServerFS(static)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", fileServer)
+	mux.Handle("GET /assets/", fileServer)
+	mux.Handle("/", api)
+	return mux
+}
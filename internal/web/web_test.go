@@ -0,0 +1,37 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerServesDashboardAndPassesAPIThrough(t *testing.T) {
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "api "+r.Method+" "+r.URL.Path)
+	})
+	handler := Handler(api)
+
+	cases := []struct {
+		method string
+		target string
+		want   string
+	}{
+		{http.MethodGet, "/", "<title>cadence</title>"},
+		{http.MethodGet, "/assets/app.js", "new EventSource"},
+		{http.MethodGet, "/state", "api GET /state"},
+		{http.MethodPost, "/start", "api POST /start"},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(c.method, c.target, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s = %d", c.method, c.target, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), c.want) {
+			t.Fatalf("%s %s body does not contain %q", c.method, c.target, c.want)
+		}
+	}
+}
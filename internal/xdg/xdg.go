@@ -0,0 +1,35 @@
+// Package xdg locates cadence's files under the user's base directories.
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Base directory for cadence's data files, such as the history log and the task list.
+// Follows the XDG base directory spec, falling back to the config directory on other systems.
+func DataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(home, ".local")); err == nil {
+			return filepath.Join(home, ".local", "share"), nil
+		}
+	}
+	return os.UserConfigDir()
+}
+
+// Base directory for state cadence keeps between runs, such as the saved session.
+// Follows the XDG base directory spec, falling back to the cache directory on other systems.
+func StateDir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return dir, nil
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if _, err := os.Stat(filepath.Join(home, ".local")); err == nil {
+			return filepath.Join(home, ".local", "state"), nil
+		}
+	}
+	return os.UserCacheDir()
+}